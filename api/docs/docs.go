@@ -49,10 +49,16 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "User ID, only admins can filter by another user",
                         "name": "user_id",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Household ID, lists the accounts shared with the household instead, the other filters do not apply",
+                        "name": "household_id",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Name",
@@ -97,6 +103,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -132,6 +144,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/budgeting_service.CreateAccount"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, a retry with the same key returns the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -141,6 +159,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/budgeting_service.Account"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -153,6 +177,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key was used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -240,6 +276,18 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -312,6 +360,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/budgeting_service.Account"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -333,14 +387,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/budgets/all": {
+        "/admin/policies": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves all budgets",
+                "description": "Lists the Casbin policies and role assignments the gateway enforces",
                 "consumes": [
                     "application/json"
                 ],
@@ -348,71 +402,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "budgets"
-                ],
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit number",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "category_id",
-                        "name": "category_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Name",
-                        "name": "period",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Type",
-                        "name": "type",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "description": "Amount",
-                        "name": "amount",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "start_date",
-                        "name": "start_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "end_date",
-                        "name": "end_date",
-                        "in": "query"
-                    }
+                    "admin"
                 ],
+                "summary": "List policies",
                 "responses": {
                     "200": {
-                        "description": "Budgets retrieved successfully",
+                        "description": "Policies retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Budgets"
+                            "$ref": "#/definitions/models.Policies"
                         }
                     },
                     "401": {
@@ -421,6 +418,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -428,16 +431,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/budgets/create": {
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new budget",
+                "description": "Adds a policy, persists it and propagates it to all gateway replicas",
                 "consumes": [
                     "application/json"
                 ],
@@ -445,24 +446,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "budgets"
+                    "admin"
                 ],
+                "summary": "Add policy",
                 "parameters": [
                     {
-                        "description": "Budget Creation Request",
+                        "type": "string",
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Subject, object and action of the policy",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.CreateBudget"
+                            "$ref": "#/definitions/models.Policy"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Budget created successfully",
+                        "description": "Policy added successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Budget"
+                            "$ref": "#/definitions/models.Policy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -471,8 +491,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -484,16 +510,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/budgets/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves a budget by ID",
+                "description": "Removes a policy, persists the change and propagates it to all gateway replicas",
                 "consumes": [
                     "application/json"
                 ],
@@ -501,22 +525,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "budgets"
+                    "admin"
                 ],
+                "summary": "Remove policy",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Budget ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Subject, object and action of the policy",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Policy"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Budget retrieved successfully",
+                        "description": "Policy removed successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Budget"
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -525,6 +570,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -540,14 +591,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/budgets/{id}/delete": {
-            "delete": {
+        "/admin/policies/roles": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a budget by ID",
+                "description": "Makes subject inherit every policy of role, e.g. subject \"support\" and role \"user\"",
                 "consumes": [
                     "application/json"
                 ],
@@ -555,22 +606,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "budgets"
+                    "admin"
                 ],
+                "summary": "Assign role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Budget ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Subject and the role it gets",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleAssignment"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Budget deleted successfully",
+                    "201": {
+                        "description": "Role assigned successfully",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.RoleAssignment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -579,8 +651,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -592,16 +670,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/budgets/{id}/update": {
-            "put": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates an existing budget",
+                "description": "Removes a role assignment, persists the change and propagates it to all gateway replicas",
                 "consumes": [
                     "application/json"
                 ],
@@ -609,31 +685,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "budgets"
+                    "admin"
                 ],
+                "summary": "Unassign role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Budget ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
                     },
                     {
-                        "description": "Budget Update Request",
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Subject and the role it loses",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Budget"
+                            "$ref": "#/definitions/models.RoleAssignment"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Budget updated successfully",
+                        "description": "Role assignment removed successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Budget"
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -642,6 +730,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -657,14 +751,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/categories/all": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Retrieves all categories",
+        "/auth/forgot-password": {
+            "post": {
+                "description": "Sends a password reset code to the email. The response is the same whether or not an account with the email exists.",
                 "consumes": [
                     "application/json"
                 ],
@@ -672,51 +761,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "auth"
                 ],
+                "summary": "Forgot password",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit number",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Name",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Type",
-                        "name": "type",
-                        "in": "query"
+                        "description": "Email of the account",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ForgotPasswordReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Categories retrieved successfully",
+                        "description": "Reset code sent if the account exists",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Categories"
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -726,18 +799,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
                     }
                 }
             }
         },
-        "/categories/create": {
+        "/auth/logout": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new category",
+                "description": "Revokes the current access token and, if given, the session of the refresh token",
                 "consumes": [
                     "application/json"
                 ],
@@ -745,34 +824,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "auth"
                 ],
+                "summary": "Logout",
                 "parameters": [
                     {
-                        "description": "Category Creation Request",
+                        "description": "Refresh token of the session",
                         "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.CreateCategory"
+                            "$ref": "#/definitions/models.LogoutReq"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Category created successfully",
+                    "200": {
+                        "description": "Logged out successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Category"
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -786,14 +865,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/categories/{id}": {
-            "get": {
+        "/auth/logout-all": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves a category by ID",
+                "description": "Revokes every access and refresh token of the current user issued until now.\nAPI keys are not sessions and stay valid, they are revoked with DELETE /users/api-keys/{id}.",
                 "consumes": [
                     "application/json"
                 ],
@@ -801,22 +880,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
-                ],
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Category ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "auth"
                 ],
+                "summary": "Logout from all devices",
                 "responses": {
                     "200": {
-                        "description": "Category retrieved successfully",
+                        "description": "Logged out from all devices successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Category"
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -825,12 +896,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/models.Response"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -840,14 +905,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/categories/{id}/delete": {
-            "delete": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Deletes a category by ID",
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access/refresh pair. Every refresh token can be used only once;\npresenting an already used one revokes the whole session.\nWith JWT_ALLOW_HMAC disabled the request is forwarded to the auth service instead.",
                 "consumes": [
                     "application/json"
                 ],
@@ -855,32 +915,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "auth"
                 ],
+                "summary": "Refresh tokens",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Category ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Refresh token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshTokenReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Category deleted successfully",
+                        "description": "Tokens refreshed successfully",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.Tokens"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -894,14 +957,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/categories/{id}/update": {
-            "put": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Updates an existing category",
+        "/auth/reset-password": {
+            "post": {
+                "description": "Sets a new password using the code sent by forgot-password",
                 "consumes": [
                     "application/json"
                 ],
@@ -909,41 +967,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "auth"
                 ],
+                "summary": "Reset password",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Category ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Category Update Request",
+                        "description": "Reset code and new password",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Category"
+                            "$ref": "#/definitions/models.ResetPasswordReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Category updated successfully",
+                        "description": "Password reset successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Category"
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -953,18 +1005,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
                     }
                 }
             }
         },
-        "/goals/all": {
+        "/budgets/all": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves all goals",
+                "description": "Retrieves all budgets",
                 "consumes": [
                     "application/json"
                 ],
@@ -972,7 +1030,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "budgets"
                 ],
                 "parameters": [
                     {
@@ -991,46 +1049,58 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "User ID, only admins can filter by another user",
                         "name": "user_id",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "Household ID, lists the budgets shared with the household instead, the other filters do not apply",
+                        "name": "household_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "category_id",
+                        "name": "category_id",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Name",
-                        "name": "name",
+                        "name": "period",
                         "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "Target Amount",
-                        "name": "target_amount",
+                        "type": "string",
+                        "description": "Type",
+                        "name": "type",
                         "in": "query"
                     },
                     {
                         "type": "number",
-                        "description": "Current Amount",
-                        "name": "current_amount",
+                        "description": "Amount",
+                        "name": "amount",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Deadline",
-                        "name": "deadline",
+                        "description": "start_date",
+                        "name": "start_date",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Status",
-                        "name": "status",
+                        "description": "end_date",
+                        "name": "end_date",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Goals retrieved successfully",
+                        "description": "Budgets retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Goals"
+                            "$ref": "#/definitions/budgeting_service.Budgets"
                         }
                     },
                     "401": {
@@ -1039,6 +1109,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1048,14 +1124,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/goals/create": {
+        "/budgets/create": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new goal",
+                "description": "Creates a new budget",
                 "consumes": [
                     "application/json"
                 ],
@@ -1063,24 +1139,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "budgets"
                 ],
                 "parameters": [
                     {
-                        "description": "Goal Creation Request",
+                        "description": "Budget Creation Request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.CreateGoal"
+                            "$ref": "#/definitions/budgeting_service.CreateBudget"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, a retry with the same key returns the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Goal created successfully",
+                        "description": "Budget created successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Goal"
+                            "$ref": "#/definitions/budgeting_service.Budget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -1095,6 +1183,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key was used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1104,14 +1204,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/goals/{id}": {
+        "/budgets/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves a goal by ID",
+                "description": "Retrieves a budget by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1119,12 +1219,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "budgets"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Goal ID",
+                        "description": "Budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1132,9 +1232,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Goal retrieved successfully",
+                        "description": "Budget retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Goal"
+                            "$ref": "#/definitions/budgeting_service.Budget"
                         }
                     },
                     "401": {
@@ -1158,14 +1258,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/goals/{id}/delete": {
+        "/budgets/{id}/delete": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a goal by ID",
+                "description": "Deletes a budget by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1173,20 +1273,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "budgets"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Goal ID",
+                        "description": "Budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Goal deleted successfully",
+                        "description": "Budget deleted successfully",
                         "schema": {
                             "type": "string"
                         }
@@ -1212,14 +1324,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/goals/{id}/update": {
+        "/budgets/{id}/update": {
             "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates an existing goal",
+                "description": "Updates an existing budget",
                 "consumes": [
                     "application/json"
                 ],
@@ -1227,31 +1339,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "goals"
+                    "budgets"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Goal ID",
+                        "description": "Budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Goal Update Request",
+                        "description": "Budget Update Request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Goal"
+                            "$ref": "#/definitions/budgeting_service.Budget"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Goal updated successfully",
+                        "description": "Budget updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Goal"
+                            "$ref": "#/definitions/budgeting_service.Budget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -1275,14 +1393,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions/all": {
+        "/categories/all": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves all transactions",
+                "description": "Retrieves all categories",
                 "consumes": [
                     "application/json"
                 ],
@@ -1290,7 +1408,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "categories"
                 ],
                 "parameters": [
                     {
@@ -1309,14 +1427,14 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "User ID, only admins can filter by another user",
                         "name": "user_id",
                         "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "Amount",
-                        "name": "amount",
+                        "type": "string",
+                        "description": "Name",
+                        "name": "name",
                         "in": "query"
                     },
                     {
@@ -1328,9 +1446,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transactions retrieved successfully",
+                        "description": "Categories retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Transactions"
+                            "$ref": "#/definitions/budgeting_service.Categories"
                         }
                     },
                     "401": {
@@ -1348,14 +1466,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions/create": {
+        "/categories/create": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new transaction",
+                "description": "Creates a new category",
                 "consumes": [
                     "application/json"
                 ],
@@ -1363,24 +1481,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "categories"
                 ],
                 "parameters": [
                     {
-                        "description": "Transaction Creation Request",
+                        "description": "Category Creation Request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.CreateTransaction"
+                            "$ref": "#/definitions/budgeting_service.CreateCategory"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, a retry with the same key returns the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Transaction created successfully",
+                        "description": "Category created successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Transaction"
+                            "$ref": "#/definitions/budgeting_service.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -1389,6 +1519,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Response"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key was used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1398,14 +1546,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions/{id}": {
+        "/categories/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves a transaction by ID",
+                "description": "Retrieves a category by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1413,12 +1561,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "categories"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Transaction ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1426,9 +1574,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transaction retrieved successfully",
+                        "description": "Category retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Transaction"
+                            "$ref": "#/definitions/budgeting_service.Category"
                         }
                     },
                     "401": {
@@ -1452,14 +1600,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions/{id}/delete": {
+        "/categories/{id}/delete": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a transaction by ID",
+                "description": "Deletes a category by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1467,20 +1615,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "categories"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Transaction ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transaction deleted successfully",
+                        "description": "Category deleted successfully",
                         "schema": {
                             "type": "string"
                         }
@@ -1506,14 +1666,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/transactions/{id}/update": {
+        "/categories/{id}/update": {
             "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates an existing transaction",
+                "description": "Updates an existing category",
                 "consumes": [
                     "application/json"
                 ],
@@ -1521,31 +1681,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "transactions"
+                    "categories"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Transaction ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Transaction Update Request",
+                        "description": "Category Update Request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Transaction"
+                            "$ref": "#/definitions/budgeting_service.Category"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Transaction updated successfully",
+                        "description": "Category updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/budgeting_service.Transaction"
+                            "$ref": "#/definitions/budgeting_service.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
                         }
                     },
                     "401": {
@@ -1569,14 +1735,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/password": {
-            "put": {
+        "/goals/all": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "changing user password",
+                "description": "Retrieves all goals",
                 "consumes": [
                     "application/json"
                 ],
@@ -1584,35 +1750,81 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "goals"
                 ],
-                "summary": "Change User Password",
                 "parameters": [
                     {
-                        "description": "change_password",
-                        "name": "change_password",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.ChangePassword"
-                        }
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit number",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID, only admins can filter by another user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Household ID, lists the goals shared with the household instead, the other filters do not apply",
+                        "name": "household_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Target Amount",
+                        "name": "target_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Current Amount",
+                        "name": "current_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deadline",
+                        "name": "deadline",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Goals retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.Response"
+                            "$ref": "#/definitions/budgeting_service.Goals"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -1626,14 +1838,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/profile": {
-            "get": {
+        "/goals/create": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "getting user profile by user id",
+                "description": "Creates a new goal",
                 "consumes": [
                     "application/json"
                 ],
@@ -1641,24 +1853,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "goals"
+                ],
+                "parameters": [
+                    {
+                        "description": "Goal Creation Request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.CreateGoal"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, a retry with the same key returns the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Get User Profile",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Goal created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Goal"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key was used for a different request",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -1672,14 +1912,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/update": {
-            "put": {
+        "/goals/{id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "updating user profile",
+                "description": "Retrieves a goal by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1687,23 +1927,1614 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "goals"
                 ],
-                "summary": "Update User Profile",
                 "parameters": [
                     {
-                        "description": "user",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UpdateUser"
-                        }
+                        "type": "string",
+                        "description": "Goal ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Goal retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Goal"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/goals/{id}/delete": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a goal by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "goals"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Goal ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Goal deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/goals/{id}/update": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates an existing goal",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "goals"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Goal ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Goal Update Request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Goal"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Goal updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Goal"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Reports that the gateway process is running, dependencies are not checked",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Alive",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/households/accept": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Joins the household of the invitation with the role it was issued for",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "households"
+                ],
+                "summary": "Accept household invitation",
+                "parameters": [
+                    {
+                        "description": "Invitation token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AcceptHouseholdInvitationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invitation accepted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.HouseholdMembership"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/households/all": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the households the current user is a member of, with their role in each",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "households"
+                ],
+                "summary": "List households",
+                "responses": {
+                    "200": {
+                        "description": "Households retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Households"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/households/create": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a household that shares accounts, budgets and goals between its members. The creator becomes its owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "households"
+                ],
+                "summary": "Create household",
+                "parameters": [
+                    {
+                        "description": "Household name",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateHouseholdReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Household created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Household"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/households/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a household with its members and shared resources",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "households"
+                ],
+                "summary": "Get household",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Household ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Household retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.HouseholdDetails"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/households/{id}/invite": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a single-use invitation token with a role of owner, editor or viewer.\nIf email is set, only the user with that email can accept it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "households"
+                ],
+                "summary": "Invite household member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Household ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Invitee email and role",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.InviteHouseholdMemberReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Invitation created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.HouseholdInvitation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/households/{id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Owners can remove any member, every member can remove themselves. The last owner can not leave.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "households"
+                ],
+                "summary": "Remove household member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Household ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID of the member",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Member removed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/households/{id}/resources/{type}/{resource_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Household owners and the owner of the resource can stop sharing it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "households"
+                ],
+                "summary": "Stop sharing resource",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Household ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource type: accounts, budgets or goals",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource ID",
+                        "name": "resource_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resource unshared successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/households/{id}/share": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Shares one of your accounts, budgets or goals with a household where you are an owner or editor.\nMembers then access it according to their household role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "households"
+                ],
+                "summary": "Share resource with household",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Household ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Resource type (accounts, budgets, goals) and ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SharedResource"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Resource shared successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.SharedResource"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Reports whether the gateway can serve requests, with the status of each dependency",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ready",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "A critical dependency is down",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/all": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves all transactions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit number",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID, only admins can filter by another user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Amount",
+                        "name": "amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transactions retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Transactions"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/create": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "parameters": [
+                    {
+                        "description": "Transaction Creation Request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.CreateTransaction"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key of the request, a retry with the same key returns the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Transaction created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Transaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key was used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves a transaction by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Transaction"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{id}/delete": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a transaction by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{id}/update": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates an existing transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Transaction Update Request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Transaction"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Transaction updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/budgeting_service.Transaction"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/2fa": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Disables two-factor authentication. Requires a step-up proof in the X-OTP or X-Step-Up-Token header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Disable TOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current TOTP code",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication disabled successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Generates a TOTP secret for an authenticator app. Two-factor authentication is enabled only after /users/2fa/verify.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Start TOTP enrollment",
+                "responses": {
+                    "200": {
+                        "description": "TOTP secret generated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.TOTPEnrollment"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/2fa/step-up": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Exchanges a TOTP code or a recovery code for a short-lived token that unlocks sensitive routes.\nSend it in the X-Step-Up-Token header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Step up with second factor",
+                "parameters": [
+                    {
+                        "description": "TOTP code or recovery code",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StepUpReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Step-up token issued successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.StepUpToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/2fa/verify": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Enables two-factor authentication with the first code from the authenticator app and returns one-time recovery codes.\nThe recovery codes are shown only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2fa"
+                ],
+                "summary": "Finish TOTP enrollment",
+                "parameters": [
+                    {
+                        "description": "Code from the authenticator app",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TOTPCodeReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication enabled successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the API keys of the current user without their secret part",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "API keys retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeys"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a long-lived API key for automation clients. The key is shown only once;\nsend it in the X-API-Key header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Create API key",
+                "parameters": [
+                    {
+                        "description": "API key name, scopes and lifetime",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "API key created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes an API key of the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Revoke API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "changing user password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change User Password",
+                "parameters": [
+                    {
+                        "description": "change_password",
+                        "name": "change_password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChangePassword"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Current TOTP code, required if two-factor authentication is enabled",
+                        "name": "X-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Step-up token, alternative to X-OTP",
+                        "name": "X-Step-Up-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/profile": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "getting user profile by user id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get User Profile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/update": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "updating user profile",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update User Profile",
+                "parameters": [
+                    {
+                        "description": "user",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/models.Response"
                         }
@@ -1728,19 +3559,242 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "budgeting_service.Account": {
+        }
+    },
+    "definitions": {
+        "budgeting_service.Account": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.Accounts": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/budgeting_service.Account"
+                    }
+                }
+            }
+        },
+        "budgeting_service.Budget": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "period": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.Budgets": {
+            "type": "object",
+            "properties": {
+                "budgets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/budgeting_service.Budget"
+                    }
+                }
+            }
+        },
+        "budgeting_service.Categories": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/budgeting_service.Category"
+                    }
+                }
+            }
+        },
+        "budgeting_service.Category": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.CreateAccount": {
             "type": "object",
             "properties": {
                 "balance": {
                     "type": "number"
                 },
+                "currency": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.CreateBudget": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "period": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.CreateCategory": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.CreateGoal": {
+            "type": "object",
+            "properties": {
+                "current_amount": {
+                    "type": "number"
+                },
+                "deadline": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "target_amount": {
+                    "type": "number"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.CreateTransaction": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "number"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.Goal": {
+            "type": "object",
+            "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "currency": {
+                "current_amount": {
+                    "type": "number"
+                },
+                "deadline": {
                     "type": "string"
                 },
                 "id": {
@@ -1749,6 +3803,55 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "status": {
+                    "type": "string"
+                },
+                "target_amount": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "budgeting_service.Goals": {
+            "type": "object",
+            "properties": {
+                "goals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/budgeting_service.Goal"
+                    }
+                }
+            }
+        },
+        "budgeting_service.Transaction": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "number"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
                 "type": {
                     "type": "string"
                 },
@@ -1760,193 +3863,248 @@ const docTemplate = `{
                 }
             }
         },
-        "budgeting_service.Accounts": {
+        "budgeting_service.Transactions": {
             "type": "object",
             "properties": {
-                "accounts": {
+                "transactions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/budgeting_service.Account"
+                        "$ref": "#/definitions/budgeting_service.Transaction"
+                    }
+                }
+            }
+        },
+        "health.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "critical": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "dependencies": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.DependencyStatus"
                     }
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         },
-        "budgeting_service.Budget": {
+        "models.APIKey": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "number"
-                },
-                "category_id": {
-                    "type": "string"
-                },
                 "created_at": {
                     "type": "string"
                 },
-                "end_date": {
+                "expires_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "period": {
+                "name": {
                     "type": "string"
                 },
-                "start_date": {
+                "prefix": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
-        "budgeting_service.Budgets": {
+        "models.APIKeys": {
             "type": "object",
             "properties": {
-                "budgets": {
+                "api_keys": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/budgeting_service.Budget"
+                        "$ref": "#/definitions/models.APIKey"
                     }
                 }
             }
         },
-        "budgeting_service.Categories": {
+        "models.AcceptHouseholdInvitationReq": {
             "type": "object",
             "properties": {
-                "categories": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/budgeting_service.Category"
-                    }
+                "token": {
+                    "type": "string"
                 }
             }
         },
-        "budgeting_service.Category": {
+        "models.ChangePassword": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "current_password": {
                     "type": "string"
                 },
-                "id": {
+                "new_password": {
                     "type": "string"
+                }
+            }
+        },
+        "models.CreateAPIKeyReq": {
+            "type": "object",
+            "properties": {
+                "expires_in_days": {
+                    "type": "integer"
                 },
                 "name": {
                     "type": "string"
                 },
-                "type": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
-                },
-                "user_id": {
-                    "type": "string"
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "budgeting_service.CreateAccount": {
+        "models.CreateHouseholdReq": {
             "type": "object",
             "properties": {
-                "balance": {
-                    "type": "number"
-                },
-                "currency": {
-                    "type": "string"
-                },
                 "name": {
                     "type": "string"
-                },
-                "type": {
-                    "type": "string"
-                },
-                "user_id": {
-                    "type": "string"
                 }
             }
         },
-        "budgeting_service.CreateBudget": {
+        "models.CreatedAPIKey": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "number"
+                "created_at": {
+                    "type": "string"
                 },
-                "category_id": {
+                "expires_at": {
                     "type": "string"
                 },
-                "end_date": {
+                "id": {
                     "type": "string"
                 },
-                "period": {
+                "key": {
                     "type": "string"
                 },
-                "start_date": {
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
                     "type": "string"
                 },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
-        "budgeting_service.CreateCategory": {
+        "models.ForgotPasswordReq": {
             "type": "object",
             "properties": {
-                "name": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Household": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "type": {
+                "id": {
                     "type": "string"
                 },
-                "user_id": {
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
                     "type": "string"
                 }
             }
         },
-        "budgeting_service.CreateGoal": {
+        "models.HouseholdDetails": {
             "type": "object",
             "properties": {
-                "current_amount": {
-                    "type": "number"
+                "created_at": {
+                    "type": "string"
                 },
-                "deadline": {
+                "id": {
                     "type": "string"
                 },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HouseholdMember"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
-                "status": {
+                "owner_id": {
                     "type": "string"
                 },
-                "target_amount": {
-                    "type": "number"
+                "resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SharedResource"
+                    }
                 },
-                "user_id": {
+                "role": {
                     "type": "string"
                 }
             }
         },
-        "budgeting_service.CreateTransaction": {
+        "models.HouseholdInvitation": {
             "type": "object",
             "properties": {
-                "account_id": {
+                "email": {
                     "type": "string"
                 },
-                "amount": {
-                    "type": "number"
+                "expires_at": {
+                    "type": "string"
                 },
-                "category_id": {
+                "household_id": {
                     "type": "string"
                 },
-                "date": {
+                "invited_by": {
                     "type": "string"
                 },
-                "description": {
+                "role": {
                     "type": "string"
                 },
-                "type": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HouseholdMember": {
+            "type": "object",
+            "properties": {
+                "role": {
                     "type": "string"
                 },
                 "user_id": {
@@ -1954,115 +4112,206 @@ const docTemplate = `{
                 }
             }
         },
-        "budgeting_service.Goal": {
+        "models.HouseholdMembership": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "current_amount": {
-                    "type": "number"
-                },
-                "deadline": {
-                    "type": "string"
-                },
                 "id": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
-                "status": {
+                "owner_id": {
                     "type": "string"
                 },
-                "target_amount": {
-                    "type": "number"
-                },
-                "updated_at": {
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Households": {
+            "type": "object",
+            "properties": {
+                "households": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HouseholdMembership"
+                    }
+                }
+            }
+        },
+        "models.InviteHouseholdMemberReq": {
+            "type": "object",
+            "properties": {
+                "email": {
                     "type": "string"
                 },
-                "user_id": {
+                "role": {
                     "type": "string"
                 }
             }
         },
-        "budgeting_service.Goals": {
+        "models.LogoutReq": {
             "type": "object",
             "properties": {
-                "goals": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Policies": {
+            "type": "object",
+            "properties": {
+                "policies": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/budgeting_service.Goal"
+                        "$ref": "#/definitions/models.Policy"
+                    }
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RoleAssignment"
                     }
                 }
             }
         },
-        "budgeting_service.Transaction": {
+        "models.Policy": {
             "type": "object",
             "properties": {
-                "account_id": {
+                "action": {
                     "type": "string"
                 },
-                "amount": {
-                    "type": "number"
+                "object": {
+                    "type": "string"
                 },
-                "category_id": {
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RecoveryCodes": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.RefreshTokenReq": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResetPasswordReq": {
+            "type": "object",
+            "properties": {
+                "code": {
                     "type": "string"
                 },
-                "created_at": {
+                "email": {
                     "type": "string"
                 },
-                "date": {
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
                     "type": "string"
                 },
+                "data": {},
                 "description": {
                     "type": "string"
                 },
-                "id": {
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RoleAssignment": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SharedResource": {
+            "type": "object",
+            "properties": {
+                "resource_id": {
                     "type": "string"
                 },
                 "type": {
                     "type": "string"
+                }
+            }
+        },
+        "models.StepUpReq": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
                 },
-                "updated_at": {
+                "recovery_code": {
                     "type": "string"
+                }
+            }
+        },
+        "models.StepUpToken": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer"
                 },
-                "user_id": {
+                "step_up_token": {
                     "type": "string"
                 }
             }
         },
-        "budgeting_service.Transactions": {
+        "models.TOTPCodeReq": {
             "type": "object",
             "properties": {
-                "transactions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/budgeting_service.Transaction"
-                    }
+                "code": {
+                    "type": "string"
                 }
             }
         },
-        "models.ChangePassword": {
+        "models.TOTPEnrollment": {
             "type": "object",
             "properties": {
-                "current_password": {
+                "otpauth_url": {
                     "type": "string"
                 },
-                "new_password": {
+                "secret": {
                     "type": "string"
                 }
             }
         },
-        "models.Response": {
+        "models.Tokens": {
             "type": "object",
             "properties": {
-                "data": {},
-                "description": {
+                "access_token": {
                     "type": "string"
                 },
-                "statusCode": {
-                    "type": "integer"
+                "refresh_token": {
+                    "type": "string"
                 }
             }
         },
@@ -2089,6 +4338,11 @@ const docTemplate = `{
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
+        },
+        "XApiKey": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// Check probes a dependency, it returns an error when the dependency is unusable
+type Check func(ctx context.Context) error
+
+// DependencyStatus is the result of the check of one dependency
+type DependencyStatus struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Latency  string `json:"latency"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the readiness of the gateway with the status of each dependency
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+type dependency struct {
+	name     string
+	critical bool
+	check    Check
+}
+
+// Checker serves the liveness and readiness probes. The gateway is ready while all critical
+// dependencies are up, the others are only reported.
+type Checker struct {
+	dependencies []dependency
+	timeout      time.Duration
+}
+
+// NewChecker builds a checker that gives every check timeout to answer
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a dependency, it must be called before the probes are served
+func (c *Checker) Add(name string, critical bool, check Check) {
+	c.dependencies = append(c.dependencies, dependency{name: name, critical: critical, check: check})
+}
+
+// Run checks all dependencies concurrently
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{
+		Status:       StatusReady,
+		Dependencies: make(map[string]DependencyStatus, len(c.dependencies)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, d := range c.dependencies {
+		wg.Add(1)
+		go func(d dependency) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := d.check(checkCtx)
+
+			result := DependencyStatus{
+				Status:   StatusUp,
+				Critical: d.critical,
+				Latency:  time.Since(start).Round(time.Microsecond).String(),
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[d.name] = result
+			if err != nil && d.critical {
+				report.Status = StatusNotReady
+			}
+		}(d)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Liveness godoc
+// @Router          /healthz [get]
+// @Description     Reports that the gateway process is running, dependencies are not checked
+// @Tags            health
+// @Produce         json
+// @Success         200 {object} map[string]string "Alive"
+func (c *Checker) Liveness(ctx *fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{"status": StatusUp})
+}
+
+// Readiness godoc
+// @Router          /readyz [get]
+// @Description     Reports whether the gateway can serve requests, with the status of each dependency
+// @Tags            health
+// @Produce         json
+// @Success         200 {object} health.Report "Ready"
+// @Failure         503 {object} health.Report "A critical dependency is down"
+func (c *Checker) Readiness(ctx *fiber.Ctx) error {
+	report := c.Run(ctx.Context())
+
+	status := fiber.StatusOK
+	if report.Status != StatusReady {
+		status = fiber.StatusServiceUnavailable
+	}
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+
+	return ctx.Status(status).JSON(report)
+}
+
+// GrpcCheck reports a connection as up only when it is ready or becomes ready within the time of the
+// check. An idle connection is asked to connect first, so that it is not reported as up before the
+// backend was reached.
+func GrpcCheck(conn *grpc.ClientConn) Check {
+	return func(ctx context.Context) error {
+		for {
+			state := conn.GetState()
+			switch state {
+			case connectivity.Ready:
+				return nil
+			case connectivity.Shutdown:
+				return errors.New("connection is " + state.String())
+			case connectivity.Idle:
+				conn.Connect()
+			}
+
+			if !conn.WaitForStateChange(ctx, state) {
+				return errors.New("connection is " + state.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/logger"
+	"api_gateway/pkg/requestid"
+	"api_gateway/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// CacheStatusHeader tells whether the response was served from the response cache
+	CacheStatusHeader = "X-Cache"
+	// HouseholdQuery selects the resources shared with a household on list routes
+	HouseholdQuery = "household_id"
+
+	invalidateKey = "cache_invalidate"
+)
+
+// ResponseCache keeps successful GET responses per user for ttl. A user's entries are invalidated
+// when the user writes through the gateway and when a Kafka event about the user arrives.
+type ResponseCache struct {
+	storage storage.IStorage
+	ttl     time.Duration
+	log     logger.ILogger
+	group   singleflight.Group
+}
+
+func NewResponseCache(storage storage.IStorage, ttl time.Duration, log logger.ILogger) *ResponseCache {
+	return &ResponseCache{
+		storage: storage,
+		ttl:     ttl,
+		log:     log,
+	}
+}
+
+// Cached serves a GET route from the cache. Identical concurrent misses share one call to the handler.
+// The cache is skipped when it is unreachable and when the client sends Cache-Control: no-cache.
+// Household lists are never cached: they change with the writes and the membership of other users,
+// and the handler has to check the membership of the caller on every request. It must run after JWTMiddleware.
+func (c *ResponseCache) Cached(ctx *fiber.Ctx) error {
+	claims, err := GetClaims(ctx)
+	if err != nil || c.ttl <= 0 || ctx.Method() != fiber.MethodGet || ctx.Query(HouseholdQuery) != "" {
+		return ctx.Next()
+	}
+
+	version, err := c.storage.ResponseCache().Version(ctx.Context(), claims.UserId)
+	if err != nil {
+		RequestLogger(ctx, c.log).Warn("response cache is unavailable", logger.Error(err))
+		return ctx.Next()
+	}
+	key := cacheKey(ctx, claims, version)
+
+	if !strings.Contains(ctx.Get(fiber.HeaderCacheControl), "no-cache") {
+		cached, err := c.storage.ResponseCache().Get(ctx.Context(), key)
+		if err == nil {
+			ctx.Set(CacheStatusHeader, "HIT")
+			return writeCached(ctx, cached)
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			RequestLogger(ctx, c.log).Warn("error while reading response cache", logger.Error(err))
+		}
+	}
+
+	var leader bool
+	res, err, _ := c.group.Do(key, func() (interface{}, error) {
+		leader = true
+
+		if err := ctx.Next(); err != nil {
+			return nil, err
+		}
+
+		response := &models.CachedResponse{
+			StatusCode:  ctx.Response().StatusCode(),
+			ContentType: string(ctx.Response().Header.ContentType()),
+			Body:        append([]byte(nil), ctx.Response().Body()...),
+		}
+		if response.StatusCode == fiber.StatusOK {
+			if err := c.storage.ResponseCache().Set(ctx.Context(), key, response, c.ttl); err != nil {
+				RequestLogger(ctx, c.log).Warn("error while writing response cache", logger.Error(err))
+			}
+		}
+
+		return response, nil
+	})
+	if leader {
+		ctx.Set(CacheStatusHeader, "MISS")
+		return err
+	}
+	if err != nil {
+		// the shared call failed, this request tries on its own
+		return ctx.Next()
+	}
+
+	ctx.Set(CacheStatusHeader, "MISS")
+	return writeCached(ctx, res.(*models.CachedResponse))
+}
+
+// Invalidate drops the cached responses of the caller after a successful write, together with those
+// of the users added by InvalidateCacheOf. Handlers use that when the caller writes a resource of another user.
+func (c *ResponseCache) Invalidate(ctx *fiber.Ctx) error {
+	if ctx.Method() == fiber.MethodGet || ctx.Method() == fiber.MethodHead {
+		return ctx.Next()
+	}
+
+	err := ctx.Next()
+
+	status := ctx.Response().StatusCode()
+	if err != nil || status < 200 || status >= 300 {
+		return err
+	}
+
+	userIds, _ := ctx.Locals(invalidateKey).([]string)
+	if claims, err := GetClaims(ctx); err == nil {
+		userIds = append(userIds, claims.UserId)
+	}
+	if err := c.storage.ResponseCache().Invalidate(ctx.Context(), userIds...); err != nil {
+		RequestLogger(ctx, c.log).Error("error while invalidating response cache", logger.Error(err))
+	}
+
+	return nil
+}
+
+// HandleEvent invalidates the cache of the user a Kafka event is about. Events carry the user_id
+// of the resource they change.
+func (c *ResponseCache) HandleEvent(ctx context.Context, topic string, value []byte) {
+	log := logger.WithFields(c.log, logger.String("request_id", requestid.FromContext(ctx)))
+
+	event := struct {
+		UserId string `json:"user_id"`
+	}{}
+	if err := json.Unmarshal(value, &event); err != nil || event.UserId == "" {
+		log.Warn("event without user_id can not invalidate the response cache", logger.String("topic", topic))
+		return
+	}
+
+	if err := c.storage.ResponseCache().Invalidate(ctx, event.UserId); err != nil {
+		log.Error("error while invalidating response cache", logger.String("topic", topic), logger.Error(err))
+	}
+}
+
+// InvalidateCacheOf makes Invalidate drop the cache of userId as well
+func InvalidateCacheOf(ctx *fiber.Ctx, userId string) {
+	userIds, _ := ctx.Locals(invalidateKey).([]string)
+	ctx.Locals(invalidateKey, append(userIds, userId))
+}
+
+// cacheKey identifies a response by the user, the cache version, the response format, the path
+// and the normalized query string
+func cacheKey(ctx *fiber.Ctx, claims *jwt.Claims, version int64) string {
+	// Encode sorts the parameters by name
+	query, _ := url.ParseQuery(string(ctx.Request().URI().QueryString()))
+
+	format := FormatLegacy
+	if UsesProblemFormat(ctx) {
+		format = FormatProblem
+	}
+
+	return claims.UserId + ":" + strconv.FormatInt(version, 10) + ":" + format + ":" + ctx.Path() + "?" + query.Encode()
+}
+
+func writeCached(ctx *fiber.Ctx, cached *models.CachedResponse) error {
+	ctx.Set(fiber.HeaderContentType, cached.ContentType)
+	return ctx.Status(cached.StatusCode).Send(cached.Body)
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"api_gateway/pkg/jwt"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestResponseCacheHousehold(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantCalls int
+		wantCache []string
+	}{
+		{"a list of the caller is cached", "/accounts/all?page=1", 1, []string{"MISS", "HIT"}},
+		{"a household list is not cached", "/accounts/all?household_id=household", 2, []string{"", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewResponseCache(newFakeStorage(), time.Minute, testLog)
+
+			calls := 0
+			app := fiber.New()
+			withClaims(app, &jwt.Claims{UserId: "user"})
+			app.Get("/accounts/all", cache.Cached, func(ctx *fiber.Ctx) error {
+				calls++
+				return ctx.SendString("accounts")
+			})
+
+			for i, want := range tt.wantCache {
+				resp, _ := request(t, app, fiber.MethodGet, tt.path, "", nil)
+				if got := resp.Header.Get(CacheStatusHeader); got != want {
+					t.Errorf("request %d: %s = %q, want %q", i, CacheStatusHeader, got, want)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// cacheCall is one request of a scenario, sent by userId
+type cacheCall struct {
+	userId    string
+	method    string
+	path      string
+	headers   map[string]string
+	wantCache string
+}
+
+func TestResponseCache(t *testing.T) {
+	get := func(userId, path, wantCache string) cacheCall {
+		return cacheCall{userId: userId, method: fiber.MethodGet, path: path, wantCache: wantCache}
+	}
+
+	tests := []struct {
+		name     string
+		calls    []cacheCall
+		wantRuns int
+	}{
+		{
+			name:     "a repeated list is served from the cache",
+			calls:    []cacheCall{get("alice", "/accounts/all", "MISS"), get("alice", "/accounts/all", "HIT")},
+			wantRuns: 1,
+		},
+		{
+			name:     "the order of the query parameters does not matter",
+			calls:    []cacheCall{get("alice", "/accounts/all?page=1&limit=10", "MISS"), get("alice", "/accounts/all?limit=10&page=1", "HIT")},
+			wantRuns: 1,
+		},
+		{
+			name:     "users do not share entries",
+			calls:    []cacheCall{get("alice", "/accounts/all", "MISS"), get("bob", "/accounts/all", "MISS")},
+			wantRuns: 2,
+		},
+		{
+			name: "no-cache skips the cached entry",
+			calls: []cacheCall{
+				get("alice", "/accounts/all", "MISS"),
+				{userId: "alice", method: fiber.MethodGet, path: "/accounts/all", headers: map[string]string{fiber.HeaderCacheControl: "no-cache"}, wantCache: "MISS"},
+			},
+			wantRuns: 2,
+		},
+		{
+			name: "a write invalidates the entries of the writer",
+			calls: []cacheCall{
+				get("alice", "/accounts/all", "MISS"),
+				{userId: "alice", method: fiber.MethodPost, path: "/accounts/create"},
+				get("alice", "/accounts/all", "MISS"),
+			},
+			wantRuns: 2,
+		},
+		{
+			name: "a write invalidates the entries of the owner of the resource",
+			calls: []cacheCall{
+				get("bob", "/accounts/all", "MISS"),
+				{userId: "admin", method: fiber.MethodPut, path: "/accounts/bob/update"},
+				get("bob", "/accounts/all", "MISS"),
+			},
+			wantRuns: 2,
+		},
+		{
+			name: "a failed write does not invalidate",
+			calls: []cacheCall{
+				get("alice", "/accounts/all", "MISS"),
+				{userId: "alice", method: fiber.MethodPut, path: "/accounts/missing/update"},
+				get("alice", "/accounts/all", "HIT"),
+			},
+			wantRuns: 1,
+		},
+		{
+			name:     "an error response is not cached",
+			calls:    []cacheCall{get("alice", "/accounts/all?page=0", "MISS"), get("alice", "/accounts/all?page=0", "MISS")},
+			wantRuns: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewResponseCache(newFakeStorage(), time.Minute, testLog)
+
+			runs := 0
+			app := fiber.New()
+			app.Use(userHeader, cache.Invalidate)
+			app.Get("/accounts/all", cache.Cached, func(ctx *fiber.Ctx) error {
+				runs++
+				if ctx.QueryInt("page", 1) < 1 {
+					return ctx.SendStatus(fiber.StatusBadRequest)
+				}
+				return ctx.SendString("accounts of " + ctx.Get("X-User"))
+			})
+			app.Post("/accounts/create", func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusCreated)
+			})
+			app.Put("/accounts/:owner/update", func(ctx *fiber.Ctx) error {
+				if ctx.Params("owner") == "missing" {
+					return ctx.SendStatus(fiber.StatusNotFound)
+				}
+				InvalidateCacheOf(ctx, strings.Clone(ctx.Params("owner")))
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+
+			for i, call := range tt.calls {
+				headers := map[string]string{"X-User": call.userId}
+				for name, value := range call.headers {
+					headers[name] = value
+				}
+
+				resp, body := request(t, app, call.method, call.path, "", headers)
+				if got := resp.Header.Get(CacheStatusHeader); got != call.wantCache {
+					t.Fatalf("request %d: %s = %q, want %q", i, CacheStatusHeader, got, call.wantCache)
+				}
+				if call.method == fiber.MethodGet && resp.StatusCode == fiber.StatusOK && body != "accounts of "+call.userId {
+					t.Errorf("request %d: body %q was served to %s", i, body, call.userId)
+				}
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("handler ran %d times, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestResponseCacheHandleEvent(t *testing.T) {
+	store := newFakeStorage()
+	cache := NewResponseCache(store, time.Minute, testLog)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantVer int64
+	}{
+		{"an event invalidates its user", `{"user_id":"alice"}`, 1},
+		{"an event without user_id is ignored", `{"id":"account-1"}`, 1},
+		{"an invalid event is ignored", `not json`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache.HandleEvent(context.Background(), "accounts", []byte(tt.value))
+			if version := store.cache.versions["alice"]; version != tt.wantVer {
+				t.Errorf("version of alice = %d, want %d", version, tt.wantVer)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/pkg/logger"
+	"api_gateway/storage"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// IdempotencyKeyHeader lets a client retry a create request without creating a duplicate
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response that was stored for an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency makes a request with an Idempotency-Key header run only once. The first response is stored
+// for ttl and returned again to retries with the same key and body. While the first request is in flight,
+// retries get 409; the reservation expires after lockTTL in case the replica dies. Responses with a 5xx
+// status are not stored, so the request can be retried. It must run after JWTMiddleware.
+func Idempotency(storage storage.IStorage, ttl, lockTTL time.Duration, log logger.ILogger) func(ctx *fiber.Ctx) error {
+
+	return func(ctx *fiber.Ctx) error {
+		idempotencyKey := ctx.Get(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			return ctx.Next()
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return abort(ctx, models.CodeValidationFailed, "Idempotency-Key is too long", nil)
+		}
+
+		// keys are scoped to the caller and the route, so clients can not see each other's responses
+		key := principal(ctx) + ":" + ctx.Method() + ":" + ctx.Path() + ":" + idempotencyKey
+		fingerprint := requestFingerprint(ctx)
+
+		stored, reserved, err := storage.Idempotency().Reserve(ctx.Context(), key, fingerprint, lockTTL)
+		if err != nil {
+			return abortInternal(ctx, log, "error while reserving idempotency key", err)
+		}
+
+		if !reserved {
+			if stored.Fingerprint != fingerprint {
+				return abort(ctx, models.CodeIdempotencyKeyReused, "Idempotency-Key was already used for a different request", nil)
+			}
+			if !stored.Completed {
+				return abort(ctx, models.CodeIdempotencyInProgress, "request with this Idempotency-Key is still being processed", nil)
+			}
+
+			ctx.Set(IdempotentReplayedHeader, "true")
+			ctx.Set(fiber.HeaderContentType, stored.ContentType)
+			return ctx.Status(stored.StatusCode).Send(stored.Body)
+		}
+
+		err = ctx.Next()
+
+		status := ctx.Response().StatusCode()
+		if err != nil || status >= fiber.StatusInternalServerError {
+			if releaseErr := storage.Idempotency().Release(ctx.Context(), key); releaseErr != nil {
+				RequestLogger(ctx, log).Error("error while releasing idempotency key", logger.Error(releaseErr))
+			}
+			return err
+		}
+
+		err = storage.Idempotency().Complete(ctx.Context(), key, &models.IdempotentResponse{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			StatusCode:  status,
+			ContentType: string(ctx.Response().Header.ContentType()),
+			Body:        append([]byte(nil), ctx.Response().Body()...),
+		}, ttl)
+		if err != nil {
+			// the request was processed, the client must still get its response
+			RequestLogger(ctx, log).Error("error while storing idempotent response", logger.Error(err))
+		}
+
+		return nil
+	}
+}
+
+// requestFingerprint identifies the content of a request, a key reused with another body is refused
+func requestFingerprint(ctx *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(ctx.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(ctx.Path()))
+	hash.Write([]byte{0})
+	hash.Write(ctx.Body())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
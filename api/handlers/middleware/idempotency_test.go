@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// idempotentCall is one request of a scenario, sent by userId with key and body
+type idempotentCall struct {
+	userId       string
+	key          string
+	body         string
+	wantStatus   int
+	wantReplayed bool
+}
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name      string
+		failFirst bool
+		calls     []idempotentCall
+		wantRuns  int
+	}{
+		{
+			name: "a retry gets the first response",
+			calls: []idempotentCall{
+				{"alice", "key", `{"name":"a"}`, fiber.StatusCreated, false},
+				{"alice", "key", `{"name":"a"}`, fiber.StatusCreated, true},
+			},
+			wantRuns: 1,
+		},
+		{
+			name: "a key reused with another body is refused",
+			calls: []idempotentCall{
+				{"alice", "key", `{"name":"a"}`, fiber.StatusCreated, false},
+				{"alice", "key", `{"name":"b"}`, fiber.StatusUnprocessableEntity, false},
+			},
+			wantRuns: 1,
+		},
+		{
+			name: "keys are scoped to the caller",
+			calls: []idempotentCall{
+				{"alice", "key", `{"name":"a"}`, fiber.StatusCreated, false},
+				{"bob", "key", `{"name":"a"}`, fiber.StatusCreated, false},
+			},
+			wantRuns: 2,
+		},
+		{
+			name:      "a failed request can be retried",
+			failFirst: true,
+			calls: []idempotentCall{
+				{"alice", "key", `{"name":"a"}`, fiber.StatusInternalServerError, false},
+				{"alice", "key", `{"name":"a"}`, fiber.StatusCreated, false},
+				{"alice", "key", `{"name":"a"}`, fiber.StatusCreated, true},
+			},
+			wantRuns: 2,
+		},
+		{
+			name: "requests without a key always run",
+			calls: []idempotentCall{
+				{"alice", "", `{"name":"a"}`, fiber.StatusCreated, false},
+				{"alice", "", `{"name":"a"}`, fiber.StatusCreated, false},
+			},
+			wantRuns: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStorage()
+
+			runs := 0
+			app := fiber.New()
+			app.Use(userHeader)
+			app.Post("/accounts/create", Idempotency(store, time.Hour, time.Minute, testLog), func(ctx *fiber.Ctx) error {
+				runs++
+				if tt.failFirst && runs == 1 {
+					return ctx.SendStatus(fiber.StatusInternalServerError)
+				}
+				return ctx.Status(fiber.StatusCreated).SendString("account-" + strconv.Itoa(runs))
+			})
+
+			var first string
+			for i, call := range tt.calls {
+				headers := map[string]string{"X-User": call.userId}
+				if call.key != "" {
+					headers[IdempotencyKeyHeader] = call.key
+				}
+
+				resp, body := request(t, app, fiber.MethodPost, "/accounts/create", call.body, headers)
+				if resp.StatusCode != call.wantStatus {
+					t.Fatalf("request %d: status = %d, want %d: %s", i, resp.StatusCode, call.wantStatus, body)
+				}
+				if replayed := resp.Header.Get(IdempotentReplayedHeader) == "true"; replayed != call.wantReplayed {
+					t.Fatalf("request %d: replayed = %v, want %v", i, replayed, call.wantReplayed)
+				}
+				if call.wantReplayed && body != first {
+					t.Errorf("request %d: replayed body %q, want %q", i, body, first)
+				}
+				if resp.StatusCode == fiber.StatusCreated && first == "" {
+					first = body
+				}
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("handler ran %d times, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
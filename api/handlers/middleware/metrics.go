@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"api_gateway/pkg/metrics"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	unmatchedRoute  = "unmatched"
+	middlewareRoute = "middleware"
+)
+
+// endpoints holds the method and path of the routes of an app that are not middleware, by app.
+// It is built on the first request, when all routes are registered.
+var endpoints sync.Map
+
+// Metrics counts requests and observes their latency. Requests are labelled by route template, so that
+// IDs in paths do not create a series each.
+func Metrics(ctx *fiber.Ctx) error {
+	start := time.Now()
+
+	err := ctx.Next()
+
+	route := routeTemplate(ctx, err)
+	metrics.HTTPRequests.WithLabelValues(ctx.Method(), route, strconv.Itoa(responseStatus(ctx, err))).Inc()
+	metrics.HTTPRequestDuration.WithLabelValues(ctx.Method(), route).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+// routeTemplate is the path of the route that handled the request. Requests that match no route
+// and requests a middleware answered before they reached their route, like a 401 of JWTMiddleware,
+// share a label each instead of the path prefix of the last middleware.
+func routeTemplate(ctx *fiber.Ctx, err error) string {
+	var e *fiber.Error
+	if errors.As(err, &e) && e.Code == fiber.StatusNotFound {
+		return unmatchedRoute
+	}
+	if !isEndpoint(ctx) {
+		return middlewareRoute
+	}
+	return ctx.Route().Path
+}
+
+// isEndpoint reports whether the current route of ctx is an endpoint. While a middleware registered by
+// Use or Group runs, the current route is the middleware's, its path is the prefix it was registered on.
+func isEndpoint(ctx *fiber.Ctx) bool {
+	routes, ok := endpoints.Load(ctx.App())
+	if !ok {
+		set := map[string]bool{}
+		for _, route := range ctx.App().GetRoutes(true) {
+			set[route.Method+" "+route.Path] = true
+		}
+		routes, _ = endpoints.LoadOrStore(ctx.App(), set)
+	}
+
+	return routes.(map[string]bool)[ctx.Route().Method+" "+ctx.Route().Path]
+}
+
+// responseStatus is the status the client gets, an error returned by the handlers is yet to be
+// written by the error handler
+func responseStatus(ctx *fiber.Ctx, err error) int {
+	if err == nil {
+		return ctx.Response().StatusCode()
+	}
+
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRouteTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    string
+	}{
+		{"a handled request is labelled by its route", fiber.MethodGet, "/accounts/account-1", map[string]string{"X-User": "alice"}, "/accounts/:id"},
+		{"a request refused by group middleware", fiber.MethodGet, "/accounts/account-1", nil, middlewareRoute},
+		{"a request refused by app middleware", fiber.MethodGet, "/accounts/account-1", map[string]string{"X-Block": "true"}, middlewareRoute},
+		{"a request that matches no route", fiber.MethodGet, "/unknown/path", map[string]string{"X-User": "alice"}, unmatchedRoute},
+		{"a request that matches no route of its group", fiber.MethodGet, "/accounts/account-1/unknown", map[string]string{"X-User": "alice"}, unmatchedRoute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			app := fiber.New()
+			app.Use(func(ctx *fiber.Ctx) error {
+				err := ctx.Next()
+				got = routeTemplate(ctx, err)
+				return err
+			}, func(ctx *fiber.Ctx) error {
+				if ctx.Get("X-Block") != "" {
+					return ctx.SendStatus(fiber.StatusForbidden)
+				}
+				return ctx.Next()
+			})
+
+			accounts := app.Group("/accounts", func(ctx *fiber.Ctx) error {
+				if ctx.Get("X-User") == "" {
+					return ctx.SendStatus(fiber.StatusUnauthorized)
+				}
+				return ctx.Next()
+			})
+			accounts.Get("/:id", func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+
+			request(t, app, tt.method, tt.path, "", tt.headers)
+			if got != tt.want {
+				t.Errorf("routeTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
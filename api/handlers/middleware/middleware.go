@@ -2,63 +2,156 @@ package middleware
 
 import (
 	"api_gateway/api/handlers/models"
+	"api_gateway/pkg/apikey"
 	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/logger"
+	"api_gateway/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gofiber/fiber/v2"
 )
 
+const (
+	// ClaimsKey is the ctx.Locals key under which JWTMiddleware stores the parsed token claims
+	ClaimsKey = "claims"
+	// APIKeyHeader carries an API key instead of the Authorization bearer token
+	APIKeyHeader = "X-API-Key"
+)
+
+// RoleResolver returns the current role of a user. It returns storage.ErrNotFound for a user that no longer exists.
+type RoleResolver func(ctx context.Context, userId string) (string, error)
+
 type casbinPermission struct {
-	enforcer *casbin.Enforcer
+	enforcer *casbin.SyncedEnforcer
 }
 
-func JWTMiddleware(enforcer *casbin.Enforcer) func(ctx *fiber.Ctx) error {
+func JWTMiddleware(enforcer *casbin.SyncedEnforcer, storage storage.IStorage, verifier *jwt.Verifier, roles RoleResolver, log logger.ILogger) func(ctx *fiber.Ctx) error {
 
 	casbinPermission := casbinPermission{
 		enforcer: enforcer,
 	}
 
 	return func(ctx *fiber.Ctx) error {
-		auth := ctx.Get("Authorization")
-		if auth == "" {
-			return ctx.Status(401).JSON(models.Response{
-				StatusCode:  401,
-				Description: "no Authorization header",
-				Data:        nil,
-			})
-		}
+		var (
+			claims *jwt.Claims
+			err    error
+		)
 
-		claims, err := jwt.ExtractClaims(auth)
-		if err != nil {
-			return ctx.Status(401).JSON(models.Response{
-				StatusCode:  401,
-				Description: "Invalid token",
-				Data:        err.Error(),
-			})
+		if key := ctx.Get(APIKeyHeader); key != "" {
+			claims, err = apiKeyClaims(ctx, storage.APIKey(), roles, key)
+			if err != nil {
+				return abortInternal(ctx, log, "error while checking API key", err)
+			}
+			if claims == nil {
+				return abort(ctx, models.CodeAuthAPIKeyInvalid, "Invalid API key", nil)
+			}
+
+			scope := apikey.RequiredScope(ctx.Method(), ctx.Path())
+			if scope == "" || !claims.HasScope(scope) {
+				return abort(ctx, models.CodeAuthInsufficientScope, "API key does not have the required scope", scope)
+			}
+		} else {
+			auth := ctx.Get("Authorization")
+			if auth == "" {
+				return abort(ctx, models.CodeAuthRequired, "no Authorization header", nil)
+			}
+
+			claims, err = verifier.ExtractClaims(auth)
+			if err != nil {
+				if errors.Is(err, jwt.ErrTokenExpired) {
+					return abort(ctx, models.CodeAuthTokenExpired, "Token is expired", err.Error())
+				}
+				return abort(ctx, models.CodeAuthTokenInvalid, "Invalid token", err.Error())
+			}
+
+			revoked, err := storage.Token().IsTokenRevoked(ctx.Context(), claims.Id, claims.UserId, claims.Email, time.Unix(claims.IssuedAt, 0))
+			if err != nil {
+				return abortInternal(ctx, log, "error while checking token revocation", err)
+			}
+			if revoked {
+				return abort(ctx, models.CodeAuthTokenRevoked, "Token is revoked", nil)
+			}
 		}
-		role := claims["role"].(string)
 
-		allow, err := casbinPermission.checkPermission(ctx, role)
+		allow, err := casbinPermission.checkPermission(ctx, claims.Role)
 		if err != nil {
-			return ctx.Status(401).JSON(models.Response{
-				StatusCode:  401,
-				Description: "error while enforcing",
-				Data:        err.Error(),
-			})
+			return abortInternal(ctx, log, "error while enforcing", err)
 		}
 		if !allow {
-			return ctx.Status(401).JSON(models.Response{
-				StatusCode:  401,
-				Description: "You don't have right permission",
-				Data:        nil,
-			})
+			return abort(ctx, models.CodeAuthForbidden, "You don't have right permission", nil)
 		}
 
+		ctx.Locals(ClaimsKey, claims)
+
 		return ctx.Next()
 	}
 
 }
 
+// GetClaims returns the claims stored by JWTMiddleware
+func GetClaims(ctx *fiber.Ctx) (*jwt.Claims, error) {
+	claims, ok := ctx.Locals(ClaimsKey).(*jwt.Claims)
+	if !ok || claims == nil {
+		return nil, fmt.Errorf("authorization is required")
+	}
+
+	return claims, nil
+}
+
+// RequireRole lets through only principals with one of roles. It must run after JWTMiddleware.
+// Unlike the Casbin check it does not depend on the editable policy.
+func RequireRole(roles ...string) func(ctx *fiber.Ctx) error {
+
+	return func(ctx *fiber.Ctx) error {
+		claims, err := GetClaims(ctx)
+		if err != nil {
+			return abort(ctx, models.CodeAuthRequired, "authorization is required", nil)
+		}
+
+		for _, role := range roles {
+			if claims.Role == role {
+				return ctx.Next()
+			}
+		}
+
+		return abort(ctx, models.CodeAuthForbidden, "You don't have right permission", nil)
+	}
+}
+
+// apiKeyClaims authenticates an API key and presents its owner the same way a token would.
+// It returns nil claims for an unknown key. The role is looked up on every use, so a key never
+// keeps a role its owner has lost. API keys are not subject to token revocation, logging out
+// of all devices keeps them valid; a key ends only when it is deleted or expires.
+func apiKeyClaims(ctx *fiber.Ctx, apiKeys storage.IAPIKeyStorage, roles RoleResolver, key string) (*jwt.Claims, error) {
+	record, err := apiKeys.GetByHash(ctx.Context(), apikey.Hash(key))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := roles(ctx.Context(), record.UserId)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &jwt.Claims{
+		Id:     record.Id,
+		Type:   jwt.TokenTypeAPIKey,
+		UserId: record.UserId,
+		Role:   role,
+		Scopes: record.Scopes,
+	}, nil
+}
+
 func (c *casbinPermission) checkPermission(ctx *fiber.Ctx, role string) (bool, error) {
 	subject := role
 	object := ctx.Path()
@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/configs"
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/logger"
+	"api_gateway/storage"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+var testLog = logger.NewLogger("test", logger.LevelError, os.DevNull)
+
+func testConfig() *configs.Config {
+	return &configs.Config{
+		SigningKeyAccess:  "access-secret",
+		SigningKeyRefresh: "refresh-secret",
+		AccessTokenTTL:    time.Hour,
+		RefreshTokenTTL:   24 * time.Hour,
+		StepUpSigningKey:  "step-up-secret",
+		StepUpTokenTTL:    5 * time.Minute,
+		JWTLeeway:         30 * time.Second,
+		JWTAllowHMAC:      true,
+	}
+}
+
+// withClaims authenticates every request of app as claims, in place of JWTMiddleware
+func withClaims(app *fiber.App, claims *jwt.Claims) {
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals(ClaimsKey, claims)
+		return ctx.Next()
+	})
+}
+
+// userHeader authenticates every request as the user named by its X-User header, in place of JWTMiddleware
+func userHeader(ctx *fiber.Ctx) error {
+	// the header is only valid during the request, the claims are kept after it like real ones
+	ctx.Locals(ClaimsKey, &jwt.Claims{UserId: strings.Clone(ctx.Get("X-User"))})
+	return ctx.Next()
+}
+
+// request sends a request to app and returns the response together with its body
+func request(t *testing.T, app *fiber.App, method, path, body string, headers map[string]string) (*http.Response, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp, string(data)
+}
+
+// fakeStorage keeps the state of the storage used by the tests in memory. Calls to the parts it does
+// not implement panic through the nil embedded interface.
+type fakeStorage struct {
+	storage.IStorage
+	cache       *fakeResponseCache
+	throttle    *fakeThrottle
+	twoFactor   *fakeTwoFactor
+	tokens      *fakeTokens
+	idempotency *fakeIdempotency
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{
+		cache:       &fakeResponseCache{versions: map[string]int64{}, responses: map[string]*models.CachedResponse{}},
+		throttle:    &fakeThrottle{hits: map[string]int64{}},
+		twoFactor:   &fakeTwoFactor{secrets: map[string]string{}, usedSteps: map[string]bool{}},
+		tokens:      &fakeTokens{revoked: map[string]bool{}, notBefore: map[string]time.Time{}},
+		idempotency: &fakeIdempotency{responses: map[string]*models.IdempotentResponse{}},
+	}
+}
+
+func (s *fakeStorage) ResponseCache() storage.IResponseCacheStorage { return s.cache }
+func (s *fakeStorage) Throttle() storage.IThrottleStorage           { return s.throttle }
+func (s *fakeStorage) TwoFactor() storage.ITwoFactorStorage         { return s.twoFactor }
+func (s *fakeStorage) Token() storage.ITokenStorage                 { return s.tokens }
+func (s *fakeStorage) Idempotency() storage.IIdempotencyStorage     { return s.idempotency }
+
+type fakeResponseCache struct {
+	mu        sync.Mutex
+	versions  map[string]int64
+	responses map[string]*models.CachedResponse
+}
+
+func (f *fakeResponseCache) Version(ctx context.Context, userId string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.versions[userId], nil
+}
+
+func (f *fakeResponseCache) Get(ctx context.Context, key string) (*models.CachedResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	response, ok := f.responses[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return response, nil
+}
+
+func (f *fakeResponseCache) Set(ctx context.Context, key string, response *models.CachedResponse, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses[key] = response
+	return nil
+}
+
+func (f *fakeResponseCache) Invalidate(ctx context.Context, userIds ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, userId := range userIds {
+		f.versions[userId]++
+	}
+	return nil
+}
+
+type fakeIdempotency struct {
+	mu        sync.Mutex
+	responses map[string]*models.IdempotentResponse
+}
+
+func (f *fakeIdempotency) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotentResponse, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if stored, ok := f.responses[key]; ok {
+		return stored, false, nil
+	}
+	f.responses[key] = &models.IdempotentResponse{Fingerprint: fingerprint}
+	return nil, true, nil
+}
+
+func (f *fakeIdempotency) Complete(ctx context.Context, key string, response *models.IdempotentResponse, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses[key] = response
+	return nil
+}
+
+func (f *fakeIdempotency) Release(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.responses, key)
+	return nil
+}
+
+type fakeTwoFactor struct {
+	storage.ITwoFactorStorage
+	mu        sync.Mutex
+	secrets   map[string]string
+	usedSteps map[string]bool
+}
+
+func (f *fakeTwoFactor) GetSecret(ctx context.Context, userId string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secret, ok := f.secrets[userId]
+	if !ok {
+		return "", storage.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (f *fakeTwoFactor) MarkStepUsed(ctx context.Context, userId string, step int64, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := userId + ":" + strconv.FormatInt(step, 10)
+	if f.usedSteps[key] {
+		return false, nil
+	}
+	f.usedSteps[key] = true
+	return true, nil
+}
+
+type fakeTokens struct {
+	storage.ITokenStorage
+	mu        sync.Mutex
+	revoked   map[string]bool
+	notBefore map[string]time.Time
+}
+
+func (f *fakeTokens) IsTokenRevoked(ctx context.Context, jti, userId, email string, issuedAt time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.revoked[jti] {
+		return true, nil
+	}
+	for _, key := range []string{"user:" + userId, "email:" + strings.ToLower(email)} {
+		if notBefore, ok := f.notBefore[key]; ok && !issuedAt.After(notBefore) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestJWTMiddlewareRevocation(t *testing.T) {
+	issued := time.Now()
+
+	tests := []struct {
+		name   string
+		revoke func(tokens *fakeTokens, jti string)
+		want   int
+	}{
+		{"a valid token passes", func(tokens *fakeTokens, jti string) {}, fiber.StatusOK},
+		{"a logged out token is refused", func(tokens *fakeTokens, jti string) { tokens.revoked[jti] = true }, fiber.StatusUnauthorized},
+		{"another logged out token does not matter", func(tokens *fakeTokens, jti string) { tokens.revoked["other"] = true }, fiber.StatusOK},
+		{
+			name:   "logging out of all devices refuses the tokens issued before",
+			revoke: func(tokens *fakeTokens, jti string) { tokens.notBefore["user:user"] = issued.Add(time.Second) },
+			want:   fiber.StatusUnauthorized,
+		},
+		{
+			name:   "tokens issued after logging out of all devices pass",
+			revoke: func(tokens *fakeTokens, jti string) { tokens.notBefore["user:user"] = issued.Add(-time.Minute) },
+			want:   fiber.StatusOK,
+		},
+		{
+			name: "a password reset refuses the tokens issued before",
+			revoke: func(tokens *fakeTokens, jti string) {
+				tokens.notBefore["email:alice@example.com"] = issued.Add(time.Second)
+			},
+			want: fiber.StatusUnauthorized,
+		},
+	}
+
+	enforcer, err := casbin.NewSyncedEnforcer("../../../configs/model.conf", "../../../configs/policy.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			verifier := jwt.NewVerifier(cfg, nil)
+			store := newFakeStorage()
+
+			access, _, err := jwt.GenerateTokens(cfg, &jwt.UserClaims{UserId: "user", Email: "Alice@example.com", Role: "user"}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := verifier.ExtractClaims(access)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.revoke(store.tokens, claims.Id)
+
+			app := fiber.New()
+			app.Get("/users/profile", JWTMiddleware(enforcer, store, verifier, nil, testLog), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+
+			resp, body := request(t, app, fiber.MethodGet, "/users/profile", "", map[string]string{"Authorization": access})
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d: %s", resp.StatusCode, tt.want, body)
+			}
+		})
+	}
+}
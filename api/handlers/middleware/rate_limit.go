@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/logger"
+	"api_gateway/pkg/ratelimit"
+	"api_gateway/storage"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type prefixRule struct {
+	prefix string
+	rule   ratelimit.Rule
+}
+
+// RateLimiter limits requests per principal. Counters are kept in Redis so that all replicas share them,
+// while Redis is unreachable every replica counts on its own.
+type RateLimiter struct {
+	throttle storage.IThrottleStorage
+	fallback *ratelimit.Memory
+	rules    []prefixRule
+	log      logger.ILogger
+}
+
+// NewRateLimiter builds a limiter from rules keyed by path prefix, the longest matching prefix wins.
+// Paths that match no rule fall back to ratelimit.DefaultRule, without it they are not limited.
+func NewRateLimiter(throttle storage.IThrottleStorage, rules map[string]ratelimit.Rule, log logger.ILogger) *RateLimiter {
+	r := &RateLimiter{
+		throttle: throttle,
+		fallback: ratelimit.NewMemory(),
+		log:      log,
+	}
+
+	for prefix, rule := range rules {
+		r.rules = append(r.rules, prefixRule{prefix: prefix, rule: rule})
+	}
+	sort.Slice(r.rules, func(i, j int) bool {
+		return len(r.rules[i].prefix) > len(r.rules[j].prefix)
+	})
+
+	return r
+}
+
+// Handler enforces the rule of the request path. Placed after JWTMiddleware it counts per user or API key,
+// on routes without authentication it counts per client IP.
+func (r *RateLimiter) Handler(ctx *fiber.Ctx) error {
+	prefix, rule, ok := r.match(ctx.Path())
+	if !ok {
+		return ctx.Next()
+	}
+
+	key := prefix + ":" + principal(ctx)
+
+	count, allowed, err := r.throttle.Allow(ctx.Context(), key, rule.Limit, rule.Window)
+	if err != nil {
+		RequestLogger(ctx, r.log).Warn("rate limit store is unavailable, counting locally", logger.Error(err))
+		count, allowed, _ = r.fallback.Allow(ctx.Context(), key, rule.Limit, rule.Window)
+	}
+
+	_, elapsed := ratelimit.Slot(time.Now(), rule.Window)
+	reset := strconv.FormatInt(int64((rule.Window-elapsed+time.Second-1)/time.Second), 10)
+	remaining := rule.Limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	ctx.Set("RateLimit-Limit", strconv.FormatInt(rule.Limit, 10))
+	ctx.Set("RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	ctx.Set("RateLimit-Reset", reset)
+	ctx.Set("RateLimit-Policy", strconv.FormatInt(rule.Limit, 10)+";w="+strconv.FormatInt(int64(rule.Window/time.Second), 10))
+
+	if !allowed {
+		ctx.Set(fiber.HeaderRetryAfter, reset)
+		return abort(ctx, models.CodeRateLimited, "Too many requests", nil)
+	}
+
+	return ctx.Next()
+}
+
+func (r *RateLimiter) match(path string) (string, ratelimit.Rule, bool) {
+	var (
+		fallback ratelimit.Rule
+		found    bool
+	)
+
+	for _, p := range r.rules {
+		if p.prefix == ratelimit.DefaultRule {
+			fallback, found = p.rule, true
+			continue
+		}
+		if path == p.prefix || strings.HasPrefix(path, strings.TrimSuffix(p.prefix, "/")+"/") {
+			return p.prefix, p.rule, true
+		}
+	}
+
+	return ratelimit.DefaultRule, fallback, found
+}
+
+// principal identifies who the request is counted against. Only a principal JWTMiddleware authenticated
+// counts, an unverified API key header would give a client a fresh bucket with every random value.
+func principal(ctx *fiber.Ctx) string {
+	if claims, ok := ctx.Locals(ClaimsKey).(*jwt.Claims); ok && claims != nil {
+		if claims.Type == jwt.TokenTypeAPIKey {
+			return "apikey:" + claims.Id
+		}
+		return "user:" + claims.UserId
+	}
+
+	return "ip:" + ctx.IP()
+}
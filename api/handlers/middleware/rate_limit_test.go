@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/ratelimit"
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type fakeThrottle struct {
+	mu   sync.Mutex
+	hits map[string]int64
+}
+
+func (f *fakeThrottle) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.hits[key]++
+	return f.hits[key], nil
+}
+
+func (f *fakeThrottle) Allow(ctx context.Context, key string, limit int64, window time.Duration) (int64, bool, error) {
+	count, _ := f.Hit(ctx, key, window)
+	return count, count <= limit, nil
+}
+
+func TestRateLimiterPrincipal(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  func(i int) *jwt.Claims
+		headers func(i int) map[string]string
+		want    []int
+	}{
+		{
+			name:    "unauthenticated requests share the bucket of their IP",
+			headers: func(i int) map[string]string { return nil },
+			want:    []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests},
+		},
+		{
+			name:    "an unverified API key does not get its own bucket",
+			headers: func(i int) map[string]string { return map[string]string{APIKeyHeader: "key-" + strconv.Itoa(i)} },
+			want:    []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests},
+		},
+		{
+			name:    "every authenticated user has a bucket",
+			claims:  func(i int) *jwt.Claims { return &jwt.Claims{UserId: "user-" + strconv.Itoa(i)} },
+			headers: func(i int) map[string]string { return nil },
+			want:    []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusOK},
+		},
+		{
+			name: "every authenticated API key has a bucket",
+			claims: func(i int) *jwt.Claims {
+				return &jwt.Claims{Id: "key-" + strconv.Itoa(i), Type: jwt.TokenTypeAPIKey, UserId: "user"}
+			},
+			headers: func(i int) map[string]string { return nil },
+			want:    []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(&fakeThrottle{hits: map[string]int64{}}, map[string]ratelimit.Rule{
+				"/auth": {Limit: 2, Window: time.Minute},
+			}, testLog)
+
+			for i, want := range tt.want {
+				app := fiber.New()
+				app.Use(func(ctx *fiber.Ctx) error {
+					if tt.claims != nil {
+						ctx.Locals(ClaimsKey, tt.claims(i))
+					}
+					return ctx.Next()
+				})
+				app.Post("/auth/login", limiter.Handler, func(ctx *fiber.Ctx) error {
+					return ctx.SendStatus(fiber.StatusOK)
+				})
+
+				if resp, body := request(t, app, fiber.MethodPost, "/auth/login", "", tt.headers(i)); resp.StatusCode != want {
+					t.Fatalf("request %d: status = %d, want %d: %s", i, resp.StatusCode, want, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"api_gateway/pkg/logger"
+	"api_gateway/pkg/requestid"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const loggerKey = "logger"
+
+// RequestID accepts the X-Request-ID of the client or generates one, echoes it in the response
+// and gives the request a logger that adds it to every line. It must be the first middleware.
+func RequestID(log logger.ILogger) func(ctx *fiber.Ctx) error {
+
+	return func(ctx *fiber.Ctx) error {
+		id := ctx.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		ctx.Set(requestid.Header, id)
+		ctx.Locals(requestid.Key, id)
+		ctx.Locals(loggerKey, logger.WithFields(log, logger.String("request_id", id)))
+
+		return ctx.Next()
+	}
+}
+
+// RequestLogger returns the logger of the request, or log when RequestID did not run
+func RequestLogger(ctx *fiber.Ctx, log logger.ILogger) logger.ILogger {
+	if l, ok := ctx.Locals(loggerKey).(logger.ILogger); ok {
+		return l
+	}
+	return log
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/pkg/logger"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// FormatLegacy wraps every response in models.Response
+	FormatLegacy = "legacy"
+	// FormatProblem sends errors as models.Problem and successful responses without an envelope
+	FormatProblem = "problem"
+
+	// ResponseFormatHeader lets a client choose the format explicitly, it takes precedence over Accept
+	ResponseFormatHeader = "X-Response-Format"
+
+	formatKey = "response_format"
+)
+
+// ResponseFormat decides which format the response is written in. Clients opt into the problem format
+// by accepting application/problem+json, the others get defaultFormat while they are migrating.
+func ResponseFormat(defaultFormat string) func(ctx *fiber.Ctx) error {
+
+	return func(ctx *fiber.Ctx) error {
+		format := defaultFormat
+
+		switch header := strings.ToLower(ctx.Get(ResponseFormatHeader)); {
+		case header == FormatLegacy || header == FormatProblem:
+			format = header
+		case strings.Contains(ctx.Get(fiber.HeaderAccept), models.ContentTypeProblem):
+			format = FormatProblem
+		}
+
+		ctx.Locals(formatKey, format)
+
+		return ctx.Next()
+	}
+}
+
+// UsesProblemFormat reports whether the client is served in the problem format
+func UsesProblemFormat(ctx *fiber.Ctx) bool {
+	format, _ := ctx.Locals(formatKey).(string)
+	return format == FormatProblem
+}
+
+// WriteError sends an error in the format the client chose. The legacy envelope carries description and data,
+// the problem carries detail and the field violations of data.
+func WriteError(ctx *fiber.Ctx, status int, code, description, detail string, data interface{}) error {
+	if !UsesProblemFormat(ctx) {
+		return ctx.Status(status).JSON(models.Response{
+			StatusCode:  status,
+			Code:        code,
+			Description: description,
+			Data:        data,
+		})
+	}
+
+	problem := models.Problem{
+		Type:     models.ProblemType(code),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.OriginalURL(),
+		Code:     code,
+	}
+	if details, ok := data.(*models.ErrorDetails); ok {
+		problem.Errors = details.Violations
+	}
+
+	return ctx.Status(status).JSON(problem, models.ContentTypeProblem)
+}
+
+// ErrorHandler formats the errors fiber returns by itself, such as unknown routes
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	detail := http.StatusText(status)
+
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		status = e.Code
+		detail = e.Message
+	}
+
+	return WriteError(ctx, status, models.CodeOf(status), detail, detail, nil)
+}
+
+// abort stops the chain with an error from the catalog in models
+func abort(ctx *fiber.Ctx, code string, description string, data interface{}) error {
+	return WriteError(ctx, models.StatusOf(code), code, description, description, data)
+}
+
+// abortInternal stops the chain with an internal error. err is only logged, its text must not reach the client.
+func abortInternal(ctx *fiber.Ctx, log logger.ILogger, description string, err error) error {
+	RequestLogger(ctx, log).Error(description, logger.Error(err))
+	return abort(ctx, models.CodeInternal, description, nil)
+}
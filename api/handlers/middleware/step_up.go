@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/logger"
+	"api_gateway/pkg/totp"
+	"api_gateway/storage"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// OTPHeader carries a current TOTP code as step-up proof
+	OTPHeader = "X-OTP"
+	// StepUpTokenHeader carries a token issued by /users/2fa/step-up
+	StepUpTokenHeader = "X-Step-Up-Token"
+
+	otpMaxAttempts   = 5
+	otpAttemptWindow = 5 * time.Minute
+)
+
+// StepUp protects sensitive routes of users who enrolled TOTP. Such users have to present either
+// a step-up token or a current code. It must run after JWTMiddleware.
+func StepUp(storage storage.IStorage, verifier *jwt.Verifier, log logger.ILogger) func(ctx *fiber.Ctx) error {
+
+	return func(ctx *fiber.Ctx) error {
+		claims, err := GetClaims(ctx)
+		if err != nil {
+			return abort(ctx, models.CodeAuthRequired, "authorization is required", nil)
+		}
+
+		secret, enrolled, err := totpSecret(ctx.Context(), storage.TwoFactor(), claims.UserId)
+		if err != nil {
+			return abortInternal(ctx, log, "error while getting two-factor settings", err)
+		}
+		if !enrolled {
+			return ctx.Next()
+		}
+
+		if token := ctx.Get(StepUpTokenHeader); token != "" {
+			stepUp, err := verifier.ExtractStepUpClaims(token)
+			if err == nil && stepUp.UserId == claims.UserId {
+				return ctx.Next()
+			}
+		}
+
+		if code := ctx.Get(OTPHeader); code != "" {
+			allowed, err := AllowOTPAttempt(ctx.Context(), storage.Throttle(), claims.UserId)
+			if err != nil {
+				return abortInternal(ctx, log, "error while throttling one-time password attempts", err)
+			}
+			if !allowed {
+				return abort(ctx, models.CodeRateLimited, "too many one-time password attempts", nil)
+			}
+
+			ok, err := VerifyOTP(ctx.Context(), storage.TwoFactor(), claims.UserId, secret, code)
+			if err != nil {
+				return abortInternal(ctx, log, "error while verifying one-time password", err)
+			}
+			if ok {
+				return ctx.Next()
+			}
+		}
+
+		return abort(ctx, models.CodeAuthStepUpRequired, "Two-factor step-up is required", "send a valid "+OTPHeader+" or "+StepUpTokenHeader+" header")
+	}
+}
+
+// VerifyOTP checks a TOTP code and refuses a code that was already accepted once
+func VerifyOTP(ctx context.Context, twoFactor storage.ITwoFactorStorage, userId, secret, code string) (bool, error) {
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// a used step has to be remembered as long as the code is accepted, including the skew
+	return twoFactor.MarkStepUsed(ctx, userId, step, 4*totp.Period)
+}
+
+// AllowOTPAttempt limits how many second factor codes a user can try, so that codes can not be brute forced
+func AllowOTPAttempt(ctx context.Context, throttle storage.IThrottleStorage, userId string) (bool, error) {
+	hits, err := throttle.Hit(ctx, "otp:"+userId, otpAttemptWindow)
+	if err != nil {
+		return false, err
+	}
+
+	return hits <= otpMaxAttempts, nil
+}
+
+func totpSecret(ctx context.Context, twoFactor storage.ITwoFactorStorage, userId string) (string, bool, error) {
+	secret, err := twoFactor.GetSecret(ctx, userId)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return secret, true, nil
+}
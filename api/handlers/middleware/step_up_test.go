@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"api_gateway/configs"
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/totp"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStepUp(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	stepUpToken := func(userId string) func(t *testing.T, cfg *configs.Config) map[string]string {
+		return func(t *testing.T, cfg *configs.Config) map[string]string {
+			token, err := jwt.GenerateStepUpToken(cfg, userId, "user")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return map[string]string{StepUpTokenHeader: token}
+		}
+	}
+	otp := func(t *testing.T, cfg *configs.Config) map[string]string {
+		code, err := totp.Code(secret, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return map[string]string{OTPHeader: code}
+	}
+
+	tests := []struct {
+		name     string
+		enrolled bool
+		headers  func(t *testing.T, cfg *configs.Config) map[string]string
+		want     []int
+	}{
+		{
+			name:    "a user without two-factor passes",
+			headers: func(t *testing.T, cfg *configs.Config) map[string]string { return nil },
+			want:    []int{fiber.StatusOK},
+		},
+		{
+			name:     "an enrolled user without proof is refused",
+			enrolled: true,
+			headers:  func(t *testing.T, cfg *configs.Config) map[string]string { return nil },
+			want:     []int{fiber.StatusUnauthorized},
+		},
+		{
+			name:     "a step-up token passes while it is valid",
+			enrolled: true,
+			headers:  stepUpToken("user"),
+			want:     []int{fiber.StatusOK, fiber.StatusOK},
+		},
+		{
+			name:     "a step-up token of another user is refused",
+			enrolled: true,
+			headers:  stepUpToken("other"),
+			want:     []int{fiber.StatusUnauthorized},
+		},
+		{
+			name:     "an access token is not a step-up token",
+			enrolled: true,
+			headers: func(t *testing.T, cfg *configs.Config) map[string]string {
+				access, _, err := jwt.GenerateTokens(cfg, &jwt.UserClaims{UserId: "user", Role: "user"}, "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return map[string]string{StepUpTokenHeader: access}
+			},
+			want: []int{fiber.StatusUnauthorized},
+		},
+		{
+			name:     "a step-up token signed with the access key is refused",
+			enrolled: true,
+			headers: func(t *testing.T, cfg *configs.Config) map[string]string {
+				forged := *cfg
+				forged.StepUpSigningKey = cfg.SigningKeyAccess
+				return stepUpToken("user")(t, &forged)
+			},
+			want: []int{fiber.StatusUnauthorized},
+		},
+		{
+			name:     "a current code passes once",
+			enrolled: true,
+			headers:  otp,
+			want:     []int{fiber.StatusOK, fiber.StatusUnauthorized},
+		},
+		{
+			name:     "wrong codes are throttled",
+			enrolled: true,
+			headers: func(t *testing.T, cfg *configs.Config) map[string]string {
+				return map[string]string{OTPHeader: "abcdef"}
+			},
+			want: []int{
+				fiber.StatusUnauthorized, fiber.StatusUnauthorized, fiber.StatusUnauthorized, fiber.StatusUnauthorized,
+				fiber.StatusUnauthorized, fiber.StatusTooManyRequests,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			store := newFakeStorage()
+			if tt.enrolled {
+				store.twoFactor.secrets["user"] = secret
+			}
+
+			app := fiber.New()
+			withClaims(app, &jwt.Claims{UserId: "user", Role: "user"})
+			app.Delete("/accounts/:id/delete", StepUp(store, jwt.NewVerifier(cfg, nil), testLog), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(fiber.StatusOK)
+			})
+
+			headers := tt.headers(t, cfg)
+			for i, want := range tt.want {
+				if resp, body := request(t, app, fiber.MethodDelete, "/accounts/1/delete", "", headers); resp.StatusCode != want {
+					t.Fatalf("request %d: status = %d, want %d: %s", i, resp.StatusCode, want, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"api_gateway/pkg/logger"
+	"api_gateway/pkg/tracing"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for every request, continuing the trace of the W3C traceparent header
+// when the client sends one. The span is named by the route template, not by the path, and the trace ID
+// is added to the request logger. It must run after RequestID.
+func Tracing(log logger.ILogger) func(ctx *fiber.Ctx) error {
+
+	return func(ctx *fiber.Ctx) error {
+		parent := otel.GetTextMapPropagator().Extract(ctx.Context(), tracing.RequestHeaderCarrier{Header: &ctx.Request().Header})
+
+		_, span := tracing.Tracer().Start(parent, ctx.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Method()),
+				semconv.URLPath(ctx.Path()),
+				semconv.URLScheme(ctx.Protocol()),
+				semconv.ClientAddress(ctx.IP()),
+				semconv.UserAgentOriginal(ctx.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+
+		ctx.Locals(tracing.Key, span)
+		if span.SpanContext().IsValid() {
+			ctx.Locals(loggerKey, logger.WithFields(RequestLogger(ctx, log), logger.String("trace_id", span.SpanContext().TraceID().String())))
+		}
+
+		err := ctx.Next()
+
+		// the route is known only once the router matched the request
+		route := routeTemplate(ctx, err)
+		span.SetName(ctx.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+
+		if err != nil {
+			span.RecordError(err)
+		}
+		status := responseStatus(ctx, err)
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
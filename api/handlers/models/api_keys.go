@@ -0,0 +1,26 @@
+package models
+
+type APIKey struct {
+	Id        string   `json:"id"`
+	UserId    string   `json:"user_id"`
+	Name      string   `json:"name"`
+	Prefix    string   `json:"prefix"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt string   `json:"created_at"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+}
+
+type CreateAPIKeyReq struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeys struct {
+	APIKeys []*APIKey `json:"api_keys"`
+}
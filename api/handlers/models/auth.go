@@ -0,0 +1,37 @@
+package models
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type TOTPCodeReq struct {
+	Code string `json:"code"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type StepUpReq struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type StepUpToken struct {
+	StepUpToken string `json:"step_up_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
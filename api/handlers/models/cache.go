@@ -0,0 +1,8 @@
+package models
+
+// CachedResponse is a response of a GET route kept in the response cache
+type CachedResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Error codes are a stable, machine-readable classification of a failed request.
+// Clients should branch on the code, the description is meant for humans and may change.
+const (
+	CodeAuthRequired          = "AUTH_REQUIRED"
+	CodeAuthTokenInvalid      = "AUTH_TOKEN_INVALID"
+	CodeAuthTokenExpired      = "AUTH_TOKEN_EXPIRED"
+	CodeAuthTokenRevoked      = "AUTH_TOKEN_REVOKED"
+	CodeAuthAPIKeyInvalid     = "AUTH_API_KEY_INVALID"
+	CodeAuthOTPInvalid        = "AUTH_OTP_INVALID"
+	CodeAuthStepUpRequired    = "AUTH_STEP_UP_REQUIRED"
+	CodeAuthForbidden         = "AUTH_FORBIDDEN"
+	CodeAuthInsufficientScope = "AUTH_INSUFFICIENT_SCOPE"
+	CodeValidationFailed      = "VALIDATION_FAILED"
+	CodeNotFound              = "NOT_FOUND"
+	CodeConflict              = "CONFLICT"
+	CodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+	CodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	CodeRateLimited           = "RATE_LIMITED"
+	CodeUpstreamFailed        = "UPSTREAM_FAILED"
+	CodeUpstreamUnavailable   = "UPSTREAM_UNAVAILABLE"
+	CodeUpstreamTimeout       = "UPSTREAM_TIMEOUT"
+	CodeInternal              = "INTERNAL_ERROR"
+)
+
+// codeStatus is the HTTP status every code is sent with
+var codeStatus = map[string]int{
+	CodeAuthRequired:          http.StatusUnauthorized,
+	CodeAuthTokenInvalid:      http.StatusUnauthorized,
+	CodeAuthTokenExpired:      http.StatusUnauthorized,
+	CodeAuthTokenRevoked:      http.StatusUnauthorized,
+	CodeAuthAPIKeyInvalid:     http.StatusUnauthorized,
+	CodeAuthOTPInvalid:        http.StatusUnauthorized,
+	CodeAuthStepUpRequired:    http.StatusUnauthorized,
+	CodeAuthForbidden:         http.StatusForbidden,
+	CodeAuthInsufficientScope: http.StatusForbidden,
+	CodeValidationFailed:      http.StatusBadRequest,
+	CodeNotFound:              http.StatusNotFound,
+	CodeConflict:              http.StatusConflict,
+	CodeIdempotencyInProgress: http.StatusConflict,
+	CodeIdempotencyKeyReused:  http.StatusUnprocessableEntity,
+	CodeRateLimited:           http.StatusTooManyRequests,
+	CodeUpstreamFailed:        http.StatusBadGateway,
+	CodeUpstreamUnavailable:   http.StatusServiceUnavailable,
+	CodeUpstreamTimeout:       http.StatusGatewayTimeout,
+	CodeInternal:              http.StatusInternalServerError,
+}
+
+// StatusOf returns the HTTP status of code, unknown codes are internal errors
+func StatusOf(code string) int {
+	if status, ok := codeStatus[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeOf returns the generic code of an error status, it is empty for successful responses
+func CodeOf(status int) string {
+	switch {
+	case status < 400:
+		return ""
+	case status == http.StatusBadRequest:
+		return CodeValidationFailed
+	case status == http.StatusUnauthorized:
+		return CodeAuthRequired
+	case status == http.StatusForbidden:
+		return CodeAuthForbidden
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusConflict:
+		return CodeConflict
+	case status == http.StatusTooManyRequests:
+		return CodeRateLimited
+	case status == http.StatusBadGateway:
+		return CodeUpstreamFailed
+	case status == http.StatusServiceUnavailable:
+		return CodeUpstreamUnavailable
+	case status == http.StatusGatewayTimeout:
+		return CodeUpstreamTimeout
+	case status >= 500:
+		return CodeInternal
+	default:
+		return CodeValidationFailed
+	}
+}
+
+// ErrorDetails is the Data of an error returned by a backend service
+type ErrorDetails struct {
+	Message    string            `json:"message"`
+	Reason     string            `json:"reason,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Violations []FieldViolation  `json:"violations,omitempty"`
+	RetryAfter int64             `json:"retry_after,omitempty"`
+}
+
+// FieldViolation describes why a single field of the request was rejected
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// ContentTypeProblem is the media type of Problem, RFC 7807
+const ContentTypeProblem = "application/problem+json"
+
+// problemTypePrefix turns an error code into the URI that identifies the problem type
+const problemTypePrefix = "urn:moneymate:problem:"
+
+// Problem is an error in the RFC 7807 problem details format.
+// Code carries the same value as in Response and Errors lists the rejected fields.
+type Problem struct {
+	Type     string           `json:"type"`
+	Title    string           `json:"title"`
+	Status   int              `json:"status"`
+	Detail   string           `json:"detail,omitempty"`
+	Instance string           `json:"instance,omitempty"`
+	Code     string           `json:"code"`
+	Errors   []FieldViolation `json:"errors,omitempty"`
+}
+
+// ProblemType returns the type URI of code, e.g. urn:moneymate:problem:auth-token-expired
+func ProblemType(code string) string {
+	return problemTypePrefix + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
@@ -0,0 +1,56 @@
+package models
+
+type Household struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	OwnerId   string `json:"owner_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+type CreateHouseholdReq struct {
+	Name string `json:"name"`
+}
+
+type HouseholdMember struct {
+	UserId string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type SharedResource struct {
+	Type       string `json:"type"`
+	ResourceId string `json:"resource_id"`
+}
+
+type HouseholdMembership struct {
+	Household
+	Role string `json:"role"`
+}
+
+type Households struct {
+	Households []HouseholdMembership `json:"households"`
+}
+
+type HouseholdDetails struct {
+	Household
+	Role      string            `json:"role"`
+	Members   []HouseholdMember `json:"members"`
+	Resources []SharedResource  `json:"resources"`
+}
+
+type HouseholdInvitation struct {
+	Token       string `json:"token"`
+	HouseholdId string `json:"household_id"`
+	Email       string `json:"email,omitempty"`
+	Role        string `json:"role"`
+	InvitedBy   string `json:"invited_by"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+type InviteHouseholdMemberReq struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type AcceptHouseholdInvitationReq struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// IdempotentResponse is the response stored for an Idempotency-Key. While the first request
+// is being processed only the fingerprint is set and Completed is false.
+type IdempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
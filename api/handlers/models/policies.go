@@ -0,0 +1,17 @@
+package models
+
+type Policy struct {
+	Subject string `json:"subject"`
+	Object  string `json:"object"`
+	Action  string `json:"action"`
+}
+
+type RoleAssignment struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+}
+
+type Policies struct {
+	Policies []Policy         `json:"policies"`
+	Roles    []RoleAssignment `json:"roles"`
+}
@@ -1,9 +1,10 @@
 package models
 
 type Response struct {
-	StatusCode  int
-	Description string
-	Data        interface{}
+	StatusCode  int         `json:"status_code"`
+	Code        string      `json:"code"`
+	Description string      `json:"description"`
+	Data        interface{} `json:"data"`
 }
 
 type UserInfoFromToken struct {
@@ -54,7 +55,6 @@ type ForgotPasswordReq struct {
 type ResetPasswordReq struct {
 	Code        string `json:"code"`
 	NewPassword string `json:"new_password"`
-	UserId      string `json:"user_id"`
 	Email       string `json:"email"`
 }
 
@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"api_gateway/api/handlers/middleware"
+	"api_gateway/api/handlers/models"
+	"api_gateway/configs"
+	"api_gateway/pkg/logger"
+	"api_gateway/pkg/requestid"
+	"api_gateway/pkg/tracing"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/proxy"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+)
+
+// hopHeaders are meaningful only for a single connection and must not be forwarded
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// AuthProxy forwards requests under the gateway's /auth prefix to the HTTP API of the user service
+type AuthProxy struct {
+	target         string
+	prefix         string
+	upstreamPrefix string
+	healthPath     string
+	timeout        time.Duration
+	client         *fasthttp.Client
+	healthy        atomic.Bool
+	log            logger.ILogger
+}
+
+func NewAuthProxy(cfg *configs.Config, log logger.ILogger) *AuthProxy {
+	p := &AuthProxy{
+		target:         "http://" + cfg.UserServiceHttpHost + cfg.UserServiceHttpPort,
+		prefix:         "/auth",
+		upstreamPrefix: strings.TrimRight(cfg.AuthProxyUpstreamPrefix, "/"),
+		healthPath:     cfg.AuthProxyHealthPath,
+		timeout:        cfg.AuthProxyTimeout,
+		client: &fasthttp.Client{
+			NoDefaultUserAgentHeader: true,
+			DisablePathNormalizing:   true,
+			ReadTimeout:              cfg.AuthProxyTimeout,
+			WriteTimeout:             cfg.AuthProxyTimeout,
+			MaxIdleConnDuration:      time.Minute,
+		},
+		log: log,
+	}
+	p.healthy.Store(true)
+
+	return p
+}
+
+// Handler proxies the request to the user service
+func (p *AuthProxy) Handler(ctx *fiber.Ctx) error {
+	if !p.healthy.Load() {
+		return middleware.WriteError(ctx, fiber.StatusServiceUnavailable, models.CodeUpstreamUnavailable, "auth service is unavailable", "auth service is unavailable", nil)
+	}
+
+	url := p.target + p.upstreamPrefix + strings.TrimPrefix(ctx.Path(), p.prefix)
+	if query := ctx.Request().URI().QueryString(); len(query) > 0 {
+		url += "?" + string(query)
+	}
+
+	req := ctx.Request()
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+
+	// only the chain written by a trusted proxy is passed on, any other client could put any address in it
+	switch {
+	case !ctx.IsProxyTrusted():
+		req.Header.Set(fiber.HeaderXForwardedFor, ctx.IP())
+	case ctx.Get(fiber.HeaderXForwardedFor) == "":
+		req.Header.Set(fiber.HeaderXForwardedFor, ctx.Context().RemoteIP().String())
+	}
+	req.Header.Set(fiber.HeaderXForwardedHost, ctx.Hostname())
+	req.Header.Set(fiber.HeaderXForwardedProto, ctx.Protocol())
+	if id := requestid.FromContext(ctx.Context()); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	// the user service continues the trace of the gateway, not the one the client sent
+	otel.GetTextMapPropagator().Inject(tracing.Context(ctx.Context()), tracing.RequestHeaderCarrier{Header: &req.Header})
+
+	err := proxy.DoTimeout(ctx, url, p.timeout, p.client)
+	if err != nil {
+		if errors.Is(err, fasthttp.ErrTimeout) {
+			middleware.RequestLogger(ctx, p.log).Warn("auth proxy upstream timed out", logger.String("path", ctx.Path()), logger.Error(err))
+			return middleware.WriteError(ctx, fiber.StatusGatewayTimeout, models.CodeUpstreamTimeout, "auth service timed out", "auth service timed out", nil)
+		}
+
+		middleware.RequestLogger(ctx, p.log).Error("auth proxy upstream failed", logger.String("path", ctx.Path()), logger.Error(err))
+		return middleware.WriteError(ctx, fiber.StatusBadGateway, models.CodeUpstreamFailed, "auth service is unavailable", "auth service is unavailable", nil)
+	}
+
+	for _, h := range hopHeaders {
+		ctx.Response().Header.Del(h)
+	}
+
+	return nil
+}
+
+// Healthy reports the result of the last upstream health check
+func (p *AuthProxy) Healthy() bool {
+	return p.healthy.Load()
+}
+
+// HealthCheck probes the upstream every interval until ctx is done.
+// Any response below 500 counts as healthy.
+func (p *AuthProxy) HealthCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *AuthProxy) check() {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(p.target + p.healthPath)
+	req.Header.SetMethod(fiber.MethodGet)
+
+	err := p.client.DoTimeout(req, resp, p.timeout)
+	healthy := err == nil && resp.StatusCode() < fiber.StatusInternalServerError
+
+	if previous := p.healthy.Swap(healthy); previous != healthy {
+		if healthy {
+			p.log.Info("auth service upstream is healthy again", logger.String("target", p.target))
+		} else {
+			p.log.Error("auth service upstream is unhealthy", logger.String("target", p.target),
+				logger.Int("status", resp.StatusCode()), logger.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"api_gateway/configs"
+	"api_gateway/pkg/logger"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestForwardedFor(t *testing.T) {
+	// app.Test sends every request from 0.0.0.0
+	const remoteAddr = "0.0.0.0"
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		forwardedFor   string
+		want           string
+	}{
+		{"a direct client is forwarded as itself", nil, "", remoteAddr},
+		{"a direct client can not make up its address", nil, "203.0.113.7", remoteAddr},
+		{"a direct client can not extend the chain", nil, "203.0.113.7, 198.51.100.1", remoteAddr},
+		{"the chain of a trusted proxy is kept", []string{remoteAddr}, "203.0.113.7, 198.51.100.1", "203.0.113.7, 198.51.100.1"},
+		{"a trusted proxy without a chain is forwarded as itself", []string{remoteAddr}, "", remoteAddr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get(fiber.HeaderXForwardedFor)
+			}))
+			defer upstream.Close()
+
+			host, port, err := net.SplitHostPort(upstream.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := NewAuthProxy(&configs.Config{
+				UserServiceHttpHost:     host,
+				UserServiceHttpPort:     ":" + port,
+				AuthProxyUpstreamPrefix: "/auth",
+				AuthProxyTimeout:        time.Second,
+			}, logger.NewLogger("test", logger.LevelError, os.DevNull))
+
+			app := fiber.New(fiber.Config{
+				ProxyHeader:             fiber.HeaderXForwardedFor,
+				EnableTrustedProxyCheck: true,
+				TrustedProxies:          tt.trustedProxies,
+			})
+			app.Post("/auth/login", p.Handler)
+
+			req := httptest.NewRequest(fiber.MethodPost, "/auth/login", nil)
+			if tt.forwardedFor != "" {
+				req.Header.Set(fiber.HeaderXForwardedFor, tt.forwardedFor)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status = %d", resp.StatusCode)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %q, want %q", fiber.HeaderXForwardedFor, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"api_gateway/api/handlers/middleware"
 	pb "api_gateway/genproto/budgeting_service"
 	"net/http"
 
@@ -15,9 +16,13 @@ import (
 // @Accept          json
 // @Produce         json
 // @Param           body body budgeting_service.CreateAccount true "Account Creation Request"
+// @Param           Idempotency-Key header string false "Unique key of the request, a retry with the same key returns the first response"
 // @Success         201 {object} budgeting_service.Account "Account created successfully"
+// @Failure         400 {object} models.Response "Bad Request"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
+// @Failure         409 {object} models.Response "Request with the same Idempotency-Key is in progress"
+// @Failure         422 {object} models.Response "Idempotency-Key was used for a different request"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) CreateAccount(ctx *fiber.Ctx) error {
 	reqCtx := ctx.Context()
@@ -31,13 +36,13 @@ func (h *HandlerV1) CreateAccount(ctx *fiber.Ctx) error {
 
 	err = ctx.BodyParser(&req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while parsing body", 404, err.Error())
+		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
 	}
 	req.UserId = user.Id
 
 	res, err := h.services.AccountService().Create(reqCtx, &req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while creating account", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while creating account", err)
 	}
 
 	return handleResponse(ctx, h.log, "Account successfully created", 201, res)
@@ -56,6 +61,11 @@ func (h *HandlerV1) CreateAccount(ctx *fiber.Ctx) error {
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetAccountById(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	id := ctx.Params("id")
 	if len(id) < 5 {
 		return handleResponse(ctx, h.log, "Invalid id", http.StatusBadRequest, nil)
@@ -64,7 +74,14 @@ func (h *HandlerV1) GetAccountById(ctx *fiber.Ctx) error {
 	req := &pb.PrimaryKey{Id: id}
 	res, err := h.services.AccountService().GetById(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving account by ID", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving account by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceAccounts, res, actionRead)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Account not found", http.StatusNotFound, "account not found")
 	}
 
 	return handleResponse(ctx, h.log, "Account successfully retrieved", 200, res)
@@ -79,7 +96,8 @@ func (h *HandlerV1) GetAccountById(ctx *fiber.Ctx) error {
 // @Produce         json
 // @Param           page query int true "Page number"
 // @Param           limit query int true "Limit number"
-// @Param           user_id query string false "User ID"
+// @Param           user_id query string false "User ID, only admins can filter by another user"
+// @Param           household_id query string false "Household ID, lists the accounts shared with the household instead, the other filters do not apply"
 // @Param           name query string false "Name"
 // @Param           type query string false "Type"
 // @Param           balance_from query float64 false "Balance from"
@@ -87,12 +105,34 @@ func (h *HandlerV1) GetAccountById(ctx *fiber.Ctx) error {
 // @Param           currency query string false "Currency"
 // @Success         200 {object} budgeting_service.Accounts "Accounts retrieved successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetAllAccounts(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	if ctx.Query(middleware.HouseholdQuery) != "" {
+		shared, allowed, err := h.householdResources(ctx, user, resourceAccounts)
+		if err != nil {
+			return handleGrpcError(ctx, h.log, "Error while retrieving household accounts", err)
+		}
+		if !allowed {
+			return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+		}
+
+		res := &pb.Accounts{}
+		for _, resource := range shared {
+			res.Accounts = append(res.Accounts, resource.(*pb.Account))
+		}
+		return handleResponse(ctx, h.log, "Accounts successfully retrieved", 200, res)
+	}
+
 	req := &pb.AccountFilter{}
 	req.Page = int32(ctx.QueryInt("page", 1))
 	req.Limit = int32(ctx.QueryInt("limit", 10))
-	req.UserId = ctx.Query("user_id")
+	req.UserId = userIdFilter(ctx, user)
 	req.Name = ctx.Query("name")
 	req.Type = ctx.Query("type")
 	req.BalanceFrom = ctx.QueryFloat("balance_from", 0)
@@ -101,7 +141,7 @@ func (h *HandlerV1) GetAllAccounts(ctx *fiber.Ctx) error {
 
 	res, err := h.services.AccountService().GetAll(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving accounts", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving accounts", err)
 	}
 
 	return handleResponse(ctx, h.log, "Accounts successfully retrieved", 200, res)
@@ -117,26 +157,46 @@ func (h *HandlerV1) GetAllAccounts(ctx *fiber.Ctx) error {
 // @Param           id path string  true "Account id"
 // @Param           body body budgeting_service.Account true "Account Update Request"
 // @Success         200 {object} budgeting_service.Account "Account updated successfully"
+// @Failure         400 {object} models.Response "Bad Request"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) UpdateAccount(ctx *fiber.Ctx) error {
 	reqCtx := ctx.Context()
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	id := ctx.Params("id")
 	if len(id) < 5 {
 		return handleResponse(ctx, h.log, "Invalid id", http.StatusBadRequest, nil)
 	}
 
 	req := pb.Account{}
-	err := ctx.BodyParser(&req)
+	err = ctx.BodyParser(&req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while parsing body", 404, err.Error())
+		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
 	}
 	req.Id = id
 
+	account, err := h.services.AccountService().GetById(reqCtx, &pb.PrimaryKey{Id: id})
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving account by ID", err)
+	}
+	allowed, err := h.canAccess(reqCtx, user, resourceAccounts, account, actionWrite)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Account not found", http.StatusNotFound, "account not found")
+	}
+	req.UserId = account.UserId
+	middleware.InvalidateCacheOf(ctx, account.UserId)
+
 	res, err := h.services.AccountService().Update(reqCtx, &req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while updating account", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while updating account", err)
 	}
 
 	return handleResponse(ctx, h.log, "Account successfully updated", 200, res)
@@ -150,19 +210,40 @@ func (h *HandlerV1) UpdateAccount(ctx *fiber.Ctx) error {
 // @Accept          json
 // @Produce         json
 // @Param           id path string true "Account ID"
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
 // @Success         200 {string} string  "Account deleted successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) DeleteAccount(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	id := ctx.Params("id")
 	if len(id) < 5 {
 		return handleResponse(ctx, h.log, "Invalid id", http.StatusBadRequest, nil)
 	}
 	req := &pb.PrimaryKey{Id: id}
-	_, err := h.services.AccountService().Delete(ctx.Context(), req)
+
+	account, err := h.services.AccountService().GetById(ctx.Context(), req)
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving account by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceAccounts, account, actionDelete)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Account not found", http.StatusNotFound, "account not found")
+	}
+	middleware.InvalidateCacheOf(ctx, account.UserId)
+
+	_, err = h.services.AccountService().Delete(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while deleting account", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while deleting account", err)
 	}
 
 	return handleResponse(ctx, h.log, "Account successfully deleted", 200, nil)
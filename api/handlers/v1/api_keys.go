@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"api_gateway/api/handlers/models"
+	pbu "api_gateway/genproto/users"
+	"api_gateway/pkg/apikey"
+	"api_gateway/storage"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateAPIKey godoc
+// @Security        ApiKeyAuth
+// @Router          /users/api-keys [post]
+// @Summary         Create API key
+// @Description     Creates a long-lived API key for automation clients. The key is shown only once;
+// @Description     send it in the X-API-Key header.
+// @Tags            api-keys
+// @Accept          json
+// @Produce         json
+// @Param           body body models.CreateAPIKeyReq true "API key name, scopes and lifetime"
+// @Success         201 {object} models.CreatedAPIKey "API key created successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) CreateAPIKey(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	req := models.CreateAPIKeyReq{}
+	if err = ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if req.Name == "" {
+		return handleResponse(ctx, h.log, "name is required", http.StatusBadRequest, "name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return handleResponse(ctx, h.log, "scopes are required", http.StatusBadRequest, apikey.Scopes)
+	}
+	for _, scope := range req.Scopes {
+		if !apikey.ValidScope(scope) {
+			return handleResponse(ctx, h.log, "invalid scope", http.StatusBadRequest, "unknown scope: "+scope)
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		return handleResponse(ctx, h.log, "invalid expires_in_days", http.StatusBadRequest, "expires_in_days must not be negative")
+	}
+
+	key, prefix, err := apikey.Generate()
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while generating API key", http.StatusInternalServerError, err.Error())
+	}
+
+	now := time.Now()
+	record := models.APIKey{
+		Id:        uuid.NewString(),
+		UserId:    user.Id,
+		Name:      req.Name,
+		Prefix:    prefix,
+		Scopes:    req.Scopes,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+
+	var ttl time.Duration
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+		record.ExpiresAt = now.Add(ttl).Format(time.RFC3339)
+	}
+
+	err = h.storage.APIKey().Create(ctx.Context(), apikey.Hash(key), &record, ttl)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while saving API key", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "API key successfully created", http.StatusCreated, models.CreatedAPIKey{
+		APIKey: record,
+		Key:    key,
+	})
+}
+
+// GetAPIKeys godoc
+// @Security        ApiKeyAuth
+// @Router          /users/api-keys [get]
+// @Summary         List API keys
+// @Description     Lists the API keys of the current user without their secret part
+// @Tags            api-keys
+// @Accept          json
+// @Produce         json
+// @Success         200 {object} models.APIKeys "API keys retrieved successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) GetAPIKeys(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	keys, err := h.storage.APIKey().GetAllByUser(ctx.Context(), user.Id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while retrieving API keys", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "API keys successfully retrieved", http.StatusOK, models.APIKeys{APIKeys: keys})
+}
+
+// RevokeAPIKey godoc
+// @Security        ApiKeyAuth
+// @Router          /users/api-keys/{id} [delete]
+// @Summary         Revoke API key
+// @Description     Revokes an API key of the current user
+// @Tags            api-keys
+// @Accept          json
+// @Produce         json
+// @Param           id path string true "API key ID"
+// @Success         200 {object} models.Response "API key revoked successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) RevokeAPIKey(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	err = h.storage.APIKey().Delete(ctx.Context(), user.Id, ctx.Params("id"))
+	if errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "API key not found", http.StatusNotFound, "API key not found")
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while revoking API key", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "API key successfully revoked", http.StatusOK, models.Message{Message: "API key revoked"})
+}
+
+// UserRole returns the role the users service currently gives the user, API keys are authorized with it
+func (h *HandlerV1) UserRole(ctx context.Context, userId string) (string, error) {
+	user, err := h.services.UsersService().GetUserProfile(ctx, &pbu.PrimaryKey{Id: userId})
+	if status.Code(err) == codes.NotFound {
+		return "", storage.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return user.Role, nil
+}
@@ -0,0 +1,344 @@
+package v1
+
+import (
+	"api_gateway/api/handlers/middleware"
+	"api_gateway/api/handlers/models"
+	pbu "api_gateway/genproto/users"
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/logger"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RefreshToken godoc
+// @Router          /auth/refresh [post]
+// @Summary         Refresh tokens
+// @Description     Exchanges a refresh token for a new access/refresh pair. Every refresh token can be used only once;
+// @Description     presenting an already used one revokes the whole session.
+// @Description     With JWT_ALLOW_HMAC disabled the request is forwarded to the auth service instead.
+// @Tags            auth
+// @Accept          json
+// @Produce         json
+// @Param           body body models.RefreshTokenReq true "Refresh token"
+// @Success         200 {object} models.Tokens "Tokens refreshed successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) RefreshToken(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	req := models.RefreshTokenReq{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if req.RefreshToken == "" {
+		return handleResponse(ctx, h.log, "refresh_token is required", http.StatusBadRequest, "refresh_token is required")
+	}
+
+	claims, err := h.verifier.ExtractRefreshClaims(req.RefreshToken)
+	if err != nil {
+		return handleError(ctx, h.log, "invalid refresh token", tokenErrorCode(err), err.Error())
+	}
+
+	userId := claims.UserId
+	jti := claims.Id
+	familyId := claims.FamilyId
+	if jti == "" {
+		return handleError(ctx, h.log, "invalid refresh token", models.CodeAuthTokenInvalid, "refresh token is missing required claims")
+	}
+	if familyId == "" {
+		familyId = jti
+	}
+
+	revoked, err := h.storage.Token().IsTokenFamilyRevoked(reqCtx, familyId)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while checking refresh token family", http.StatusInternalServerError, err.Error())
+	}
+	if !revoked {
+		revoked, err = h.storage.Token().IsTokenRevoked(reqCtx, jti, userId, claims.Email, time.Unix(claims.IssuedAt, 0))
+		if err != nil {
+			return handleResponse(ctx, h.log, "error while checking refresh token revocation", http.StatusInternalServerError, err.Error())
+		}
+	}
+	if revoked {
+		return handleError(ctx, h.log, "refresh token is revoked", models.CodeAuthTokenRevoked, "refresh token is revoked")
+	}
+
+	user, err := h.services.UsersService().GetUserProfile(reqCtx, &pbu.PrimaryKey{Id: userId})
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "error while using GetUserProfile method of users service", err)
+	}
+	if user.Role == "" {
+		user.Role = claims.Role
+	}
+
+	accessToken, refreshToken, err := jwt.GenerateTokens(h.cfg, &jwt.UserClaims{
+		UserId:    user.Id,
+		Username:  user.Username,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Role:      user.Role,
+	}, familyId)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while generating tokens", http.StatusInternalServerError, err.Error())
+	}
+
+	// the token is used up only once the new pair exists, so a client may retry after a failure.
+	// It is accepted until the leeway after it expires and the mark has to live as long.
+	ttl := max(claims.ExpiresIn(time.Now())+h.cfg.JWTLeeway, h.cfg.JWTLeeway, time.Second)
+	firstUse, err := h.storage.Token().MarkRefreshTokenUsed(reqCtx, jti, ttl)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while marking refresh token as used", http.StatusInternalServerError, err.Error())
+	}
+	if !firstUse {
+		middleware.RequestLogger(ctx, h.log).Warn("refresh token reuse detected, revoking token family", logger.String("user_id", userId), logger.String("family_id", familyId))
+		err = h.storage.Token().RevokeTokenFamily(reqCtx, familyId, h.cfg.RefreshTokenTTL)
+		if err != nil {
+			return handleResponse(ctx, h.log, "error while revoking refresh token family", http.StatusInternalServerError, err.Error())
+		}
+		return handleError(ctx, h.log, "refresh token reuse detected", models.CodeAuthTokenRevoked, "refresh token is revoked")
+	}
+
+	return handleResponse(ctx, h.log, "Tokens successfully refreshed", http.StatusOK, models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// Logout godoc
+// @Security        ApiKeyAuth
+// @Router          /auth/logout [post]
+// @Summary         Logout
+// @Description     Revokes the current access token and, if given, the session of the refresh token
+// @Tags            auth
+// @Accept          json
+// @Produce         json
+// @Param           body body models.LogoutReq false "Refresh token of the session"
+// @Success         200 {object} models.Response "Logged out successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) Logout(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	claims, err := middleware.GetClaims(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting claims from token", http.StatusUnauthorized, err.Error())
+	}
+
+	req := models.LogoutReq{}
+	if len(ctx.Body()) > 0 {
+		if err = ctx.BodyParser(&req); err != nil {
+			return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+		}
+	}
+
+	if claims.Id != "" {
+		err = h.storage.Token().RevokeToken(reqCtx, claims.Id, claims.ExpiresIn(time.Now()))
+		if err != nil {
+			return handleResponse(ctx, h.log, "error while revoking access token", http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	if req.RefreshToken != "" {
+		refreshClaims, err := h.verifier.ExtractRefreshClaims(req.RefreshToken)
+		if err != nil {
+			return handleResponse(ctx, h.log, "invalid refresh token", http.StatusBadRequest, err.Error())
+		}
+		if refreshClaims.UserId != claims.UserId {
+			return handleResponse(ctx, h.log, "refresh token belongs to another user", http.StatusBadRequest, "invalid refresh token")
+		}
+
+		familyId := refreshClaims.FamilyId
+		if familyId == "" {
+			familyId = refreshClaims.Id
+		}
+		if familyId != "" {
+			err = h.storage.Token().RevokeTokenFamily(reqCtx, familyId, h.cfg.RefreshTokenTTL)
+			if err != nil {
+				return handleResponse(ctx, h.log, "error while revoking refresh token", http.StatusInternalServerError, err.Error())
+			}
+		}
+	}
+
+	return handleResponse(ctx, h.log, "Successfully logged out", http.StatusOK, models.Message{Message: "logged out"})
+}
+
+// LogoutAll godoc
+// @Security        ApiKeyAuth
+// @Router          /auth/logout-all [post]
+// @Summary         Logout from all devices
+// @Description     Revokes every access and refresh token of the current user issued until now.
+// @Description     API keys are not sessions and stay valid, they are revoked with DELETE /users/api-keys/{id}.
+// @Tags            auth
+// @Accept          json
+// @Produce         json
+// @Success         200 {object} models.Response "Logged out from all devices successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) LogoutAll(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	err = h.storage.Token().RevokeUserTokens(ctx.Context(), user.Id, time.Now(), h.cfg.RefreshTokenTTL)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while revoking user tokens", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Successfully logged out from all devices", http.StatusOK, models.Message{Message: "logged out from all devices"})
+}
+
+// ForgotPassword godoc
+// @Router          /auth/forgot-password [post]
+// @Summary         Forgot password
+// @Description     Sends a password reset code to the email. The response is the same whether or not an account with the email exists.
+// @Tags            auth
+// @Accept          json
+// @Produce         json
+// @Param           body body models.ForgotPasswordReq true "Email of the account"
+// @Success         200 {object} models.Response "Reset code sent if the account exists"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         429 {object} models.Response "Too Many Requests"
+// @Failure         500 {object} models.Response "Internal Server Error"
+// @Failure         503 {object} models.Response "Service Unavailable"
+func (h *HandlerV1) ForgotPassword(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	req := models.ForgotPasswordReq{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	req.Email = normalizeEmail(req.Email)
+	if !strings.Contains(req.Email, "@") {
+		return handleResponse(ctx, h.log, "invalid email", http.StatusBadRequest, "valid email is required")
+	}
+
+	allowed, err := h.throttlePasswordReset(ctx, "forgot", req.Email)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while throttling forgot password", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "too many forgot password attempts", http.StatusTooManyRequests, "too many attempts, try again later")
+	}
+
+	_, err = h.services.UsersService().ForgotPassword(reqCtx, &pbu.ForgotPasswordReq{Email: req.Email})
+	if err != nil {
+		if isUpstreamFailure(err) {
+			return handleError(ctx, h.log, "error while using ForgotPassword method of users service", models.CodeUpstreamUnavailable, "service is temporarily unavailable")
+		}
+		// Do not reveal to the client whether the account exists
+		middleware.RequestLogger(ctx, h.log).Info("ForgotPassword method of users service failed", logger.Error(err))
+	}
+
+	return handleResponse(ctx, h.log, "Forgot password request accepted", http.StatusOK, models.Message{
+		Message: "If an account with this email exists, a password reset code has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Router          /auth/reset-password [post]
+// @Summary         Reset password
+// @Description     Sets a new password using the code sent by forgot-password
+// @Tags            auth
+// @Accept          json
+// @Produce         json
+// @Param           body body models.ResetPasswordReq true "Reset code and new password"
+// @Success         200 {object} models.Response "Password reset successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         429 {object} models.Response "Too Many Requests"
+// @Failure         500 {object} models.Response "Internal Server Error"
+// @Failure         503 {object} models.Response "Service Unavailable"
+func (h *HandlerV1) ResetPassword(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	req := models.ResetPasswordReq{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	req.Email = normalizeEmail(req.Email)
+	if !strings.Contains(req.Email, "@") || req.Code == "" || req.NewPassword == "" {
+		return handleResponse(ctx, h.log, "invalid reset password request", http.StatusBadRequest, "email, code and new_password are required")
+	}
+
+	allowed, err := h.throttlePasswordReset(ctx, "reset", req.Email)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while throttling reset password", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "too many reset password attempts", http.StatusTooManyRequests, "too many attempts, try again later")
+	}
+
+	_, err = h.services.UsersService().ResetPassword(reqCtx, &pbu.ResetPasswordReq{
+		Code:        req.Code,
+		NewPassword: req.NewPassword,
+		Email:       req.Email,
+	})
+	if err != nil {
+		if isUpstreamFailure(err) {
+			return handleError(ctx, h.log, "error while using ResetPassword method of users service", models.CodeUpstreamUnavailable, "service is temporarily unavailable")
+		}
+		middleware.RequestLogger(ctx, h.log).Info("ResetPassword method of users service failed", logger.Error(err))
+		return handleResponse(ctx, h.log, "reset password rejected", http.StatusBadRequest, "invalid or expired reset code")
+	}
+
+	// the sessions opened with the old password must not outlive it
+	err = h.storage.Token().RevokeEmailTokens(reqCtx, req.Email, time.Now(), h.cfg.RefreshTokenTTL)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while revoking user tokens", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Password successfully reset", http.StatusOK, models.Message{Message: "password has been reset"})
+}
+
+// throttlePasswordReset counts the attempt against both the email and the client IP
+func (h *HandlerV1) throttlePasswordReset(ctx *fiber.Ctx, action, email string) (bool, error) {
+	reqCtx := ctx.Context()
+
+	ipHits, err := h.storage.Throttle().Hit(reqCtx, "password_"+action+":ip:"+ctx.IP(), h.cfg.PasswordResetWindow)
+	if err != nil {
+		return false, err
+	}
+	emailHits, err := h.storage.Throttle().Hit(reqCtx, "password_"+action+":email:"+email, h.cfg.PasswordResetWindow)
+	if err != nil {
+		return false, err
+	}
+
+	if ipHits > int64(h.cfg.PasswordResetIPLimit) || emailHits > int64(h.cfg.PasswordResetEmailLimit) {
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(h.cfg.PasswordResetWindow.Seconds())))
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// tokenErrorCode tells an expired token apart from one that is invalid for any other reason
+func tokenErrorCode(err error) string {
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return models.CodeAuthTokenExpired
+	}
+	return models.CodeAuthTokenInvalid
+}
+
+// isUpstreamFailure reports whether err means the backend could not be reached
+// rather than that it rejected the request
+func isUpstreamFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	pbu "api_gateway/genproto/users"
+	"api_gateway/pkg/jwt"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRefreshTokenAfterPasswordReset(t *testing.T) {
+	tests := []struct {
+		name       string
+		reset      bool
+		wantStatus int
+	}{
+		{"refresh works without a reset", false, http.StatusOK},
+		{"a reset revokes the refresh tokens issued before it", true, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &pbu.User{Id: "user-1", Email: "alice@example.com", Role: "user"}
+			services := &fakeServices{users: &fakeUsers{
+				users:      map[string]*pbu.User{user.Id: user},
+				resetCodes: map[string]string{user.Email: "123456"},
+			}}
+			h := newTestHandler(services, newFakeStorage())
+
+			app := fiber.New()
+			app.Post("/auth/refresh", h.RefreshToken)
+			app.Post("/auth/reset-password", h.ResetPassword)
+
+			_, refreshToken, err := jwt.GenerateTokens(h.cfg, &jwt.UserClaims{UserId: user.Id, Email: user.Email, Role: user.Role}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.reset {
+				status, body := request(t, app, http.MethodPost, "/auth/reset-password",
+					`{"email":"Alice@example.com","code":"123456","new_password":"new-password"}`, nil)
+				if status != http.StatusOK {
+					t.Fatalf("reset-password status = %d, body %s", status, body)
+				}
+			}
+
+			refreshBody, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+			status, body := request(t, app, http.MethodPost, "/auth/refresh", string(refreshBody), nil)
+			if status != tt.wantStatus {
+				t.Errorf("refresh status = %d, want %d, body %s", status, tt.wantStatus, body)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		wantStatus []int
+	}{
+		{"a used token is reuse", 0, []int{http.StatusOK, http.StatusUnauthorized}},
+		{"a retry after a failed refresh is not reuse", 1, []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusUnauthorized}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &pbu.User{Id: "user-1", Email: "alice@example.com", Role: "user"}
+			services := &fakeServices{users: &fakeUsers{users: map[string]*pbu.User{user.Id: user}, failures: tt.failures}}
+			h := newTestHandler(services, newFakeStorage())
+
+			app := fiber.New()
+			app.Post("/auth/refresh", h.RefreshToken)
+
+			_, refreshToken, err := jwt.GenerateTokens(h.cfg, &jwt.UserClaims{UserId: user.Id, Email: user.Email, Role: user.Role}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			refreshBody, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+
+			for i, want := range tt.wantStatus {
+				if status, body := request(t, app, http.MethodPost, "/auth/refresh", string(refreshBody), nil); status != want {
+					t.Fatalf("refresh %d: status = %d, want %d, body %s", i, status, want, body)
+				}
+			}
+		})
+	}
+}
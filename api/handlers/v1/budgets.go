@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"api_gateway/api/handlers/middleware"
 	pb "api_gateway/genproto/budgeting_service"
 	"encoding/json"
 	"net/http"
@@ -17,9 +18,13 @@ import (
 // @Accept          json
 // @Produce         json
 // @Param           body body budgeting_service.CreateBudget true "Budget Creation Request"
+// @Param           Idempotency-Key header string false "Unique key of the request, a retry with the same key returns the first response"
 // @Success         201 {object} budgeting_service.Budget "Budget created successfully"
+// @Failure         400 {object} models.Response "Bad Request"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
+// @Failure         409 {object} models.Response "Request with the same Idempotency-Key is in progress"
+// @Failure         422 {object} models.Response "Idempotency-Key was used for a different request"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) CreateBudget(ctx *fiber.Ctx) error {
 	reqCtx := ctx.Context()
@@ -32,22 +37,34 @@ func (h *HandlerV1) CreateBudget(ctx *fiber.Ctx) error {
 	req := pb.CreateBudget{}
 	err = ctx.BodyParser(&req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while parsing body", 404, err.Error())
+		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
 	}
 	req.UserId = user.Id
 
+	category, err := h.services.CategoryService().GetById(reqCtx, &pb.PrimaryKey{Id: req.CategoryId})
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving category by ID", err)
+	}
+	allowed, err := h.canAccess(reqCtx, user, resourceCategories, category, actionRead)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Category not found", http.StatusNotFound, "category not found")
+	}
+
 	_, err = time.Parse("04-05-2006", req.StartDate)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while parsing start_date", 404, err.Error())
+		return handleResponse(ctx, h.log, "Error while parsing start_date", http.StatusBadRequest, err.Error())
 	}
 	_, err = time.Parse("04-05-2006", req.EndDate)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while parsing end_date", 404, err.Error())
+		return handleResponse(ctx, h.log, "Error while parsing end_date", http.StatusBadRequest, err.Error())
 	}
 
 	res, err := h.services.BudgetService().Create(reqCtx, &req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while creating budget", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while creating budget", err)
 	}
 
 	return handleResponse(ctx, h.log, "Budget successfully created", 201, res)
@@ -66,6 +83,11 @@ func (h *HandlerV1) CreateBudget(ctx *fiber.Ctx) error {
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetBudgetById(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	id := ctx.Params("id")
 	if len(id) < 5 {
 		return handleResponse(ctx, h.log, "Invalid id", http.StatusBadRequest, nil)
@@ -74,7 +96,14 @@ func (h *HandlerV1) GetBudgetById(ctx *fiber.Ctx) error {
 	req := &pb.PrimaryKey{Id: id}
 	res, err := h.services.BudgetService().GetById(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving budget by ID", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving budget by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceBudgets, res, actionRead)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Budget not found", http.StatusNotFound, "budget not found")
 	}
 
 	return handleResponse(ctx, h.log, "Budget successfully retrieved", 200, res)
@@ -89,7 +118,8 @@ func (h *HandlerV1) GetBudgetById(ctx *fiber.Ctx) error {
 // @Produce         json
 // @Param           page query int true "Page number"
 // @Param           limit query int true "Limit number"
-// @Param           user_id query string false "User ID"
+// @Param           user_id query string false "User ID, only admins can filter by another user"
+// @Param           household_id query string false "Household ID, lists the budgets shared with the household instead, the other filters do not apply"
 // @Param           category_id query string false "category_id"
 // @Param           period query string false "Name"
 // @Param           type query string false "Type"
@@ -98,14 +128,35 @@ func (h *HandlerV1) GetBudgetById(ctx *fiber.Ctx) error {
 // @Param           end_date query string false "end_date"
 // @Success         200 {object} budgeting_service.Budgets "Budgets retrieved successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetAllBudgets(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	if ctx.Query(middleware.HouseholdQuery) != "" {
+		shared, allowed, err := h.householdResources(ctx, user, resourceBudgets)
+		if err != nil {
+			return handleGrpcError(ctx, h.log, "Error while retrieving household budgets", err)
+		}
+		if !allowed {
+			return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+		}
+
+		res := &pb.Budgets{}
+		for _, resource := range shared {
+			res.Budgets = append(res.Budgets, resource.(*pb.Budget))
+		}
+		return handleResponse(ctx, h.log, "Budgets successfully retrieved", http.StatusOK, res)
+	}
+
 	req := &pb.BudgetFilter{}
 	req.Page = int32(ctx.QueryInt("page", 1))
 	req.Limit = int32(ctx.QueryInt("limit", 10))
-	req.UserId = ctx.Query("user_id")
+	req.UserId = userIdFilter(ctx, user)
 	req.Amount = ctx.QueryFloat("amount")
-	req.UserId = ctx.Query("user_id")
 	req.CategoryId = ctx.Query("category_id")
 	req.Period = ctx.Query("period")
 	req.StartDate = ctx.Query("start_date")
@@ -113,7 +164,7 @@ func (h *HandlerV1) GetAllBudgets(ctx *fiber.Ctx) error {
 
 	res, err := h.services.BudgetService().GetAll(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving budgets", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving budgets", err)
 	}
 
 	return handleResponse(ctx, h.log, "Budgets successfully retrieved", 200, res)
@@ -129,10 +180,15 @@ func (h *HandlerV1) GetAllBudgets(ctx *fiber.Ctx) error {
 // @Param           id path string true "Budget ID"
 // @Param           body body budgeting_service.Budget true "Budget Update Request"
 // @Success         200 {object} budgeting_service.Budget "Budget updated successfully"
+// @Failure         400 {object} models.Response "Bad Request"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) UpdateBudget(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
 	id := ctx.Params("id")
 	if len(id) < 5 {
@@ -140,17 +196,50 @@ func (h *HandlerV1) UpdateBudget(ctx *fiber.Ctx) error {
 	}
 
 	req := pb.Budget{}
-	err := ctx.BodyParser(&req)
+	err = ctx.BodyParser(&req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while parsing body", 404, err.Error())
+		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
 	}
 	req.Id = id
+
+	budget, err := h.services.BudgetService().GetById(ctx.Context(), &pb.PrimaryKey{Id: id})
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving budget by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceBudgets, budget, actionWrite)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Budget not found", http.StatusNotFound, "budget not found")
+	}
+	req.UserId = budget.UserId
+	middleware.InvalidateCacheOf(ctx, budget.UserId)
+
+	// moving the budget to another category needs the same access to it as creating the budget
+	if req.CategoryId == "" {
+		req.CategoryId = budget.CategoryId
+	}
+	if req.CategoryId != budget.CategoryId {
+		category, err := h.services.CategoryService().GetById(ctx.Context(), &pb.PrimaryKey{Id: req.CategoryId})
+		if err != nil {
+			return handleGrpcError(ctx, h.log, "Error while retrieving category by ID", err)
+		}
+		allowed, err := h.canAccess(ctx.Context(), user, resourceCategories, category, actionRead)
+		if err != nil {
+			return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+		}
+		if !allowed {
+			return handleResponse(ctx, h.log, "Category not found", http.StatusNotFound, "category not found")
+		}
+	}
+
 	data, err := json.Marshal(&req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while marshalling request", 404, err.Error())
+		return handleResponse(ctx, h.log, "Error while marshalling request", http.StatusInternalServerError, err.Error())
 	}
 
-	err = h.iKafka.ProduceMessage("budget_updated", string(data))
+	err = h.iKafka.ProduceMessage(ctx.Context(), "budget_updated", string(data))
 	if err != nil {
 		return handleResponse(ctx, h.log, "Error while sending message", http.StatusInternalServerError, err.Error())
 	}
@@ -171,17 +260,37 @@ func (h *HandlerV1) UpdateBudget(ctx *fiber.Ctx) error {
 // @Accept          json
 // @Produce         json
 // @Param           id path string true "Budget ID"
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
 // @Success         200 {string} string  "Budget deleted successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) DeleteBudget(ctx *fiber.Ctx) error {
-	id := ctx.Params("id")
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
+	id := ctx.Params("id")
 	req := &pb.PrimaryKey{Id: id}
-	_, err := h.services.BudgetService().Delete(ctx.Context(), req)
+
+	budget, err := h.services.BudgetService().GetById(ctx.Context(), req)
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving budget by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceBudgets, budget, actionDelete)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Budget not found", http.StatusNotFound, "budget not found")
+	}
+	middleware.InvalidateCacheOf(ctx, budget.UserId)
+
+	_, err = h.services.BudgetService().Delete(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while deleting budget", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while deleting budget", err)
 	}
 
 	return handleResponse(ctx, h.log, "Budget successfully deleted", 200, nil)
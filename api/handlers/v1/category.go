@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"api_gateway/api/handlers/middleware"
 	pb "api_gateway/genproto/budgeting_service"
 	"net/http"
 
@@ -15,9 +16,13 @@ import (
 // @Accept          json
 // @Produce         json
 // @Param           body body budgeting_service.CreateCategory true "Category Creation Request"
+// @Param           Idempotency-Key header string false "Unique key of the request, a retry with the same key returns the first response"
 // @Success         201 {object} budgeting_service.Category "Category created successfully"
+// @Failure         400 {object} models.Response "Bad Request"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
+// @Failure         409 {object} models.Response "Request with the same Idempotency-Key is in progress"
+// @Failure         422 {object} models.Response "Idempotency-Key was used for a different request"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) CreateCategory(ctx *fiber.Ctx) error {
 	reqCtx := ctx.Context()
@@ -30,13 +35,13 @@ func (h *HandlerV1) CreateCategory(ctx *fiber.Ctx) error {
 	req := pb.CreateCategory{}
 	err = ctx.BodyParser(&req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusNotFound, err.Error())
+		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
 	}
 	req.UserId = user.Id
 
 	res, err := h.services.CategoryService().Create(reqCtx, &req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while creating category", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while creating category", err)
 	}
 
 	return handleResponse(ctx, h.log, "Category successfully created", http.StatusCreated, res)
@@ -55,6 +60,11 @@ func (h *HandlerV1) CreateCategory(ctx *fiber.Ctx) error {
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetCategoryById(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	id := ctx.Params("id")
 	if len(id) < 5 {
 		return handleResponse(ctx, h.log, "Invalid id", http.StatusBadRequest, nil)
@@ -63,7 +73,14 @@ func (h *HandlerV1) GetCategoryById(ctx *fiber.Ctx) error {
 	req := &pb.PrimaryKey{Id: id}
 	res, err := h.services.CategoryService().GetById(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving category by ID", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving category by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceCategories, res, actionRead)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Category not found", http.StatusNotFound, "category not found")
 	}
 
 	return handleResponse(ctx, h.log, "Category successfully retrieved", http.StatusOK, res)
@@ -78,23 +95,28 @@ func (h *HandlerV1) GetCategoryById(ctx *fiber.Ctx) error {
 // @Produce         json
 // @Param           page query int true "Page number"
 // @Param           limit query int true "Limit number"
-// @Param           user_id query string false "User ID"
+// @Param           user_id query string false "User ID, only admins can filter by another user"
 // @Param           name query string false "Name"
 // @Param           type query string false "Type"
 // @Success         200 {object} budgeting_service.Categories "Categories retrieved successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetAllCategories(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	req := &pb.CategoryFilter{}
 	req.Page = int32(ctx.QueryInt("page", 1))
 	req.Limit = int32(ctx.QueryInt("limit", 10))
-	req.UserId = ctx.Query("user_id")
+	req.UserId = userIdFilter(ctx, user)
 	req.Name = ctx.Query("name")
 	req.Type = ctx.Query("type")
 
 	res, err := h.services.CategoryService().GetAll(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving categories", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving categories", err)
 	}
 
 	return handleResponse(ctx, h.log, "Categories successfully retrieved", http.StatusOK, res)
@@ -110,11 +132,16 @@ func (h *HandlerV1) GetAllCategories(ctx *fiber.Ctx) error {
 // @Param           id path string true "Category ID"
 // @Param           body body budgeting_service.Category true "Category Update Request"
 // @Success         200 {object} budgeting_service.Category "Category updated successfully"
+// @Failure         400 {object} models.Response "Bad Request"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) UpdateCategory(ctx *fiber.Ctx) error {
 	reqCtx := ctx.Context()
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
 	id := ctx.Params("id")
 	if len(id) < 5 {
@@ -122,15 +149,29 @@ func (h *HandlerV1) UpdateCategory(ctx *fiber.Ctx) error {
 	}
 
 	req := pb.Category{}
-	err := ctx.BodyParser(&req)
+	err = ctx.BodyParser(&req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusNotFound, err.Error())
+		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
 	}
 	req.Id = id
 
+	category, err := h.services.CategoryService().GetById(reqCtx, &pb.PrimaryKey{Id: id})
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving category by ID", err)
+	}
+	allowed, err := h.canAccess(reqCtx, user, resourceCategories, category, actionWrite)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Category not found", http.StatusNotFound, "category not found")
+	}
+	req.UserId = category.UserId
+	middleware.InvalidateCacheOf(ctx, category.UserId)
+
 	res, err := h.services.CategoryService().Update(reqCtx, &req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while updating category", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while updating category", err)
 	}
 
 	return handleResponse(ctx, h.log, "Category successfully updated", http.StatusOK, res)
@@ -144,17 +185,37 @@ func (h *HandlerV1) UpdateCategory(ctx *fiber.Ctx) error {
 // @Accept          json
 // @Produce         json
 // @Param           id path string true "Category ID"
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
 // @Success         200 {string} string  "Category deleted successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) DeleteCategory(ctx *fiber.Ctx) error {
-	id := ctx.Params("id")
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
+	id := ctx.Params("id")
 	req := &pb.PrimaryKey{Id: id}
-	_, err := h.services.CategoryService().Delete(ctx.Context(), req)
+
+	category, err := h.services.CategoryService().GetById(ctx.Context(), req)
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving category by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceCategories, category, actionDelete)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Category not found", http.StatusNotFound, "category not found")
+	}
+	middleware.InvalidateCacheOf(ctx, category.UserId)
+
+	_, err = h.services.CategoryService().Delete(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while deleting category", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while deleting category", err)
 	}
 
 	return handleResponse(ctx, h.log, "Category successfully deleted", http.StatusOK, nil)
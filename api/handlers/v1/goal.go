@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"api_gateway/api/handlers/middleware"
 	pb "api_gateway/genproto/budgeting_service"
 	"encoding/json"
 	"net/http"
@@ -16,9 +17,12 @@ import (
 // @Accept          json
 // @Produce         json
 // @Param           body body budgeting_service.CreateGoal true "Goal Creation Request"
+// @Param           Idempotency-Key header string false "Unique key of the request, a retry with the same key returns the first response"
 // @Success         201 {object} budgeting_service.Goal "Goal created successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
+// @Failure         409 {object} models.Response "Request with the same Idempotency-Key is in progress"
+// @Failure         422 {object} models.Response "Idempotency-Key was used for a different request"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) CreateGoal(ctx *fiber.Ctx) error {
 	reqCtx := ctx.Context()
@@ -37,7 +41,7 @@ func (h *HandlerV1) CreateGoal(ctx *fiber.Ctx) error {
 
 	res, err := h.services.GoalService().Create(reqCtx, &req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while creating goal", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while creating goal", err)
 	}
 
 	return handleResponse(ctx, h.log, "Goal successfully created", http.StatusCreated, res)
@@ -56,6 +60,11 @@ func (h *HandlerV1) CreateGoal(ctx *fiber.Ctx) error {
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetGoalById(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	id := ctx.Params("id")
 	if len(id) < 5 {
 		return handleResponse(ctx, h.log, "Invalid id", http.StatusBadRequest, nil)
@@ -64,7 +73,14 @@ func (h *HandlerV1) GetGoalById(ctx *fiber.Ctx) error {
 	req := &pb.PrimaryKey{Id: id}
 	res, err := h.services.GoalService().GetById(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving goal by ID", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving goal by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceGoals, res, actionRead)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Goal not found", http.StatusNotFound, "goal not found")
 	}
 
 	return handleResponse(ctx, h.log, "Goal successfully retrieved", http.StatusOK, res)
@@ -79,7 +95,8 @@ func (h *HandlerV1) GetGoalById(ctx *fiber.Ctx) error {
 // @Produce         json
 // @Param           page query int true "Page number"
 // @Param           limit query int true "Limit number"
-// @Param           user_id query string false "User ID"
+// @Param           user_id query string false "User ID, only admins can filter by another user"
+// @Param           household_id query string false "Household ID, lists the goals shared with the household instead, the other filters do not apply"
 // @Param           name query string false "Name"
 // @Param           target_amount query float64 false "Target Amount"
 // @Param           current_amount query float64 false "Current Amount"
@@ -87,12 +104,34 @@ func (h *HandlerV1) GetGoalById(ctx *fiber.Ctx) error {
 // @Param           status query string false "Status"
 // @Success         200 {object} budgeting_service.Goals "Goals retrieved successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetAllGoals(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	if ctx.Query(middleware.HouseholdQuery) != "" {
+		shared, allowed, err := h.householdResources(ctx, user, resourceGoals)
+		if err != nil {
+			return handleGrpcError(ctx, h.log, "Error while retrieving household goals", err)
+		}
+		if !allowed {
+			return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+		}
+
+		res := &pb.Goals{}
+		for _, resource := range shared {
+			res.Goals = append(res.Goals, resource.(*pb.Goal))
+		}
+		return handleResponse(ctx, h.log, "Goals successfully retrieved", http.StatusOK, res)
+	}
+
 	req := &pb.GoalFilter{}
 	req.Page = int32(ctx.QueryInt("page", 1))
 	req.Limit = int32(ctx.QueryInt("limit", 10))
-	req.UserId = ctx.Query("user_id")
+	req.UserId = userIdFilter(ctx, user)
 	req.Name = ctx.Query("name")
 	req.TargetAmount = ctx.QueryFloat("target_amount", 0)
 	req.CurrentAmount = ctx.QueryFloat("current_amount", 0)
@@ -101,7 +140,7 @@ func (h *HandlerV1) GetAllGoals(ctx *fiber.Ctx) error {
 
 	res, err := h.services.GoalService().GetAll(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving goals", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving goals", err)
 	}
 
 	return handleResponse(ctx, h.log, "Goals successfully retrieved", http.StatusOK, res)
@@ -121,6 +160,10 @@ func (h *HandlerV1) GetAllGoals(ctx *fiber.Ctx) error {
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) UpdateGoal(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
 	id := ctx.Params("id")
 	if len(id) < 5 {
@@ -128,17 +171,32 @@ func (h *HandlerV1) UpdateGoal(ctx *fiber.Ctx) error {
 	}
 
 	req := pb.Goal{}
-	err := ctx.BodyParser(&req)
+	err = ctx.BodyParser(&req)
 	if err != nil {
 		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
 	}
 	req.Id = id
+
+	goal, err := h.services.GoalService().GetById(ctx.Context(), &pb.PrimaryKey{Id: id})
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving goal by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceGoals, goal, actionWrite)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Goal not found", http.StatusNotFound, "goal not found")
+	}
+	req.UserId = goal.UserId
+	middleware.InvalidateCacheOf(ctx, goal.UserId)
+
 	data, err := json.Marshal(&req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while marshalling request", 404, err.Error())
+		return handleResponse(ctx, h.log, "Error while marshalling request", http.StatusInternalServerError, err.Error())
 	}
 
-	err = h.iKafka.ProduceMessage("goal_progress_updated", string(data))
+	err = h.iKafka.ProduceMessage(ctx.Context(), "goal_progress_updated", string(data))
 	if err != nil {
 		return handleResponse(ctx, h.log, "Error while sending message", http.StatusInternalServerError, err.Error())
 	}
@@ -159,17 +217,37 @@ func (h *HandlerV1) UpdateGoal(ctx *fiber.Ctx) error {
 // @Accept          json
 // @Produce         json
 // @Param           id path string true "Goal ID"
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
 // @Success         200 {string} string  "Goal deleted successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) DeleteGoal(ctx *fiber.Ctx) error {
-	id := ctx.Params("id")
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
+	id := ctx.Params("id")
 	req := &pb.PrimaryKey{Id: id}
-	_, err := h.services.GoalService().Delete(ctx.Context(), req)
+
+	goal, err := h.services.GoalService().GetById(ctx.Context(), req)
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving goal by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceGoals, goal, actionDelete)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Goal not found", http.StatusNotFound, "goal not found")
+	}
+	middleware.InvalidateCacheOf(ctx, goal.UserId)
+
+	_, err = h.services.GoalService().Delete(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while deleting goal", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while deleting goal", err)
 	}
 
 	return handleResponse(ctx, h.log, "Goal successfully deleted", http.StatusOK, nil)
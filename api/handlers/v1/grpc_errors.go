@@ -0,0 +1,97 @@
+package v1
+
+import (
+	"api_gateway/api/handlers/middleware"
+	"api_gateway/api/handlers/models"
+	"api_gateway/pkg/logger"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcCodes translates the status codes of backend services to the error catalog.
+// Codes that are missing are failures of the backend itself and become internal errors.
+var grpcCodes = map[codes.Code]string{
+	codes.InvalidArgument:    models.CodeValidationFailed,
+	codes.FailedPrecondition: models.CodeValidationFailed,
+	codes.OutOfRange:         models.CodeValidationFailed,
+	codes.NotFound:           models.CodeNotFound,
+	codes.AlreadyExists:      models.CodeConflict,
+	codes.Aborted:            models.CodeConflict,
+	codes.PermissionDenied:   models.CodeAuthForbidden,
+	codes.ResourceExhausted:  models.CodeRateLimited,
+	codes.Unauthenticated:    models.CodeUpstreamFailed,
+	codes.Unimplemented:      models.CodeUpstreamFailed,
+	codes.Unavailable:        models.CodeUpstreamUnavailable,
+	codes.Canceled:           models.CodeUpstreamUnavailable,
+	codes.DeadlineExceeded:   models.CodeUpstreamTimeout,
+}
+
+// sanitizedMessages replace the message of errors that are not caused by the client,
+// their text can contain internal details and is only logged
+var sanitizedMessages = map[string]string{
+	models.CodeUpstreamFailed:      "service failed to process the request",
+	models.CodeUpstreamUnavailable: "service is temporarily unavailable",
+	models.CodeUpstreamTimeout:     "service did not respond in time",
+	models.CodeInternal:            internalErrorMessage,
+}
+
+const internalErrorMessage = "internal error, please try again later"
+
+// handleGrpcError responds with the error returned by a backend service. The status code decides the
+// HTTP status, the message and the structured details are passed on only when they are meant for the client.
+func handleGrpcError(ctx *fiber.Ctx, log logger.ILogger, msg string, err error) error {
+	st := status.Convert(err)
+
+	code, ok := grpcCodes[st.Code()]
+	if !ok {
+		code = models.CodeInternal
+	}
+
+	details := grpcErrorDetails(st)
+	if sanitized, ok := sanitizedMessages[code]; ok {
+		middleware.RequestLogger(ctx, log).Error("backend service error", logger.String("msg", msg), logger.String("grpc_code", st.Code().String()), logger.Error(err))
+		details = &models.ErrorDetails{Message: sanitized, RetryAfter: details.RetryAfter}
+	}
+	if details.RetryAfter > 0 {
+		ctx.Set(fiber.HeaderRetryAfter, strconv.FormatInt(details.RetryAfter, 10))
+	}
+
+	return handleError(ctx, log, msg, code, details)
+}
+
+func grpcErrorDetails(st *status.Status) *models.ErrorDetails {
+	details := &models.ErrorDetails{Message: st.Message()}
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			details.Reason = d.GetReason()
+			details.Metadata = d.GetMetadata()
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				details.Violations = append(details.Violations, models.FieldViolation{
+					Field:       v.GetField(),
+					Description: v.GetDescription(),
+				})
+			}
+		case *errdetails.PreconditionFailure:
+			for _, v := range d.GetViolations() {
+				details.Violations = append(details.Violations, models.FieldViolation{
+					Field:       v.GetSubject(),
+					Description: v.GetDescription(),
+				})
+			}
+		case *errdetails.LocalizedMessage:
+			details.Message = d.GetMessage()
+		case *errdetails.RetryInfo:
+			details.RetryAfter = int64(math.Ceil(d.GetRetryDelay().AsDuration().Seconds()))
+		}
+	}
+
+	return details
+}
@@ -1,33 +1,56 @@
 package v1
 
 import (
+	"api_gateway/api/handlers/middleware"
 	"api_gateway/api/handlers/models"
-	checker  "api_gateway/pkg/jwt"
+	"api_gateway/configs"
 	"api_gateway/grpc/client"
+	checker "api_gateway/pkg/jwt"
 	"api_gateway/pkg/logger"
 	"api_gateway/pkg/messege_brokers/kafka"
-	"fmt"
+	"api_gateway/storage"
+	"net/http"
 
+	"github.com/casbin/casbin/v2"
 	"github.com/gofiber/fiber/v2"
-	"github.com/dgrijalva/jwt-go"
 )
 
 type HandlerV1 struct {
-	services client.IServiceManager
-	log      logger.ILogger
-	iKafka   kafka.IKafka
+	services   client.IServiceManager
+	log        logger.ILogger
+	iKafka     kafka.IKafka
+	storage    storage.IStorage
+	cfg        *configs.Config
+	verifier   *checker.Verifier
+	enforcer   *casbin.SyncedEnforcer
+	households *casbin.SyncedEnforcer
 }
 
-func NewHandlerV1(services client.IServiceManager, logger logger.ILogger, iKafka kafka.IKafka) *HandlerV1 {
+func NewHandlerV1(services client.IServiceManager, logger logger.ILogger, iKafka kafka.IKafka, storage storage.IStorage, cfg *configs.Config, verifier *checker.Verifier, enforcer, households *casbin.SyncedEnforcer) *HandlerV1 {
 	return &HandlerV1{
-		services: services,
-		log:      logger,
-		iKafka:   iKafka,
+		services:   services,
+		log:        logger,
+		iKafka:     iKafka,
+		storage:    storage,
+		cfg:        cfg,
+		verifier:   verifier,
+		enforcer:   enforcer,
+		households: households,
 	}
 }
 
 func handleResponse(ctx *fiber.Ctx, log logger.ILogger, msg string, statusCode int, data interface{}) error {
+	return writeResponse(ctx, log, msg, statusCode, models.CodeOf(statusCode), data)
+}
+
+// handleError responds with an error from the catalog in models, the status is derived from the code
+func handleError(ctx *fiber.Ctx, log logger.ILogger, msg string, code string, data interface{}) error {
+	return writeResponse(ctx, log, msg, models.StatusOf(code), code, data)
+}
+
+func writeResponse(ctx *fiber.Ctx, log logger.ILogger, msg string, statusCode int, code string, data interface{}) error {
 	var resp models.Response
+	log = middleware.RequestLogger(ctx, log)
 
 	switch {
 	case statusCode >= 200 && statusCode < 300:
@@ -35,49 +58,76 @@ func handleResponse(ctx *fiber.Ctx, log logger.ILogger, msg string, statusCode i
 		log.Info("Response OK", logger.String("msg", msg), logger.Int("status", statusCode))
 	case statusCode == 400:
 		resp.Description = "Bad Request"
-		log.Warn("Bad Request", logger.String("msg", msg), logger.Int("status", statusCode), logger.Any("error", data))
+		log.Warn("Bad Request", logger.String("msg", msg), logger.Int("status", statusCode), logger.String("code", code), logger.Any("error", data))
 	case statusCode == 401:
 		resp.Description = "Unauthorized"
-		log.Warn("Unauthorized", logger.String("msg", msg), logger.Int("status", statusCode))
+		log.Warn("Unauthorized", logger.String("msg", msg), logger.Int("status", statusCode), logger.String("code", code))
+	case statusCode == 403:
+		resp.Description = "Forbidden"
+		log.Warn("Forbidden", logger.String("msg", msg), logger.Int("status", statusCode), logger.String("code", code))
+	case statusCode == 404:
+		resp.Description = "Not Found"
+		log.Warn("Not Found", logger.String("msg", msg), logger.Int("status", statusCode), logger.String("code", code), logger.Any("error", data))
+	case statusCode == 409:
+		resp.Description = "Conflict"
+		log.Warn("Conflict", logger.String("msg", msg), logger.Int("status", statusCode), logger.String("code", code))
+	case statusCode == 429:
+		resp.Description = "Too Many Requests"
+		log.Warn("Too Many Requests", logger.String("msg", msg), logger.Int("status", statusCode), logger.String("code", code))
 	case statusCode >= 500:
-		resp.Description = "Internal Server Error"
-		log.Error("Internal Server Error", logger.String("msg", msg), logger.Int("status", statusCode), logger.Any("error", data))
+		resp.Description = http.StatusText(statusCode)
+		log.Error(resp.Description, logger.String("msg", msg), logger.Int("status", statusCode), logger.String("code", code), logger.Any("error", data))
 	default:
-		resp.Description = "Unknown Error"
-		log.Error("Unknown Error", logger.String("msg", msg), logger.Int("status", statusCode), logger.Any("error", data))
+		resp.Description = http.StatusText(statusCode)
+		log.Warn(resp.Description, logger.String("msg", msg), logger.Int("status", statusCode), logger.String("code", code), logger.Any("error", data))
+	}
+
+	// the text of internal errors is only logged, it can reveal details about the backend
+	if statusCode == http.StatusInternalServerError {
+		data = &models.ErrorDetails{Message: internalErrorMessage}
+	}
+
+	if statusCode >= 400 {
+		return middleware.WriteError(ctx, statusCode, code, resp.Description, errorDetail(msg, data), data)
+	}
+
+	if middleware.UsesProblemFormat(ctx) {
+		return ctx.Status(statusCode).JSON(data)
 	}
 
 	resp.StatusCode = statusCode
+	resp.Code = code
 	resp.Data = data
 
 	return ctx.Status(statusCode).JSON(resp)
 }
 
-func getUserInfoFromToken(ctx *fiber.Ctx) (*models.UserInfoFromToken, error) {
-
-	var (
-		token  string
-		claims jwt.MapClaims
-		resp   = models.UserInfoFromToken{}
-		err    error
-	)
-
-	token = ctx.Get("Authorization")
-	if token == "" {
-		return nil, fmt.Errorf("authorization is requeired")
+// errorDetail is the human readable explanation of an error in the problem format
+func errorDetail(msg string, data interface{}) string {
+	switch d := data.(type) {
+	case string:
+		if d != "" {
+			return d
+		}
+	case *models.ErrorDetails:
+		return d.Message
 	}
 
-	claims, err = checker.ExtractClaims(token)
+	return msg
+}
+
+func getUserInfoFromToken(ctx *fiber.Ctx) (*models.UserInfoFromToken, error) {
+	claims, err := middleware.GetClaims(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	resp.Id = claims["user_id"].(string)
-	resp.Username = claims["username"].(string)
-	resp.Email = claims["email"].(string)
-	resp.FirstName = claims["first_name"].(string)
-	resp.LastName = claims["last_name"].(string)
-	resp.Role = claims["role"].(string)
-
-	return &resp, nil
+	return &models.UserInfoFromToken{
+		Id:        claims.UserId,
+		Username:  claims.Username,
+		Email:     claims.Email,
+		FirstName: claims.FirstName,
+		LastName:  claims.LastName,
+		Role:      claims.Role,
+	}, nil
 }
@@ -0,0 +1,258 @@
+package v1
+
+import (
+	"api_gateway/configs"
+	pb "api_gateway/genproto/budgeting_service"
+	pbu "api_gateway/genproto/users"
+	"api_gateway/grpc/client"
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/logger"
+	"api_gateway/storage"
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testConfig() *configs.Config {
+	return &configs.Config{
+		SigningKeyAccess:        "access-secret",
+		SigningKeyRefresh:       "refresh-secret",
+		AccessTokenTTL:          time.Hour,
+		RefreshTokenTTL:         24 * time.Hour,
+		JWTLeeway:               30 * time.Second,
+		JWTAllowHMAC:            true,
+		PasswordResetWindow:     15 * time.Minute,
+		PasswordResetEmailLimit: 3,
+		PasswordResetIPLimit:    10,
+	}
+}
+
+func newTestHandler(services client.IServiceManager, store storage.IStorage) *HandlerV1 {
+	cfg := testConfig()
+	return NewHandlerV1(services, logger.NewLogger("test", logger.LevelError, os.DevNull), nil, store, cfg, jwt.NewVerifier(cfg, nil), nil, nil)
+}
+
+// request sends a request to app and returns the status and the body of the response
+func request(t *testing.T, app *fiber.App, method, path, body string, headers map[string]string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, string(data)
+}
+
+// fakeStorage keeps the state of the storage used by the tests in memory. Calls to the parts it does
+// not implement panic through the nil embedded interface.
+type fakeStorage struct {
+	storage.IStorage
+	tokens     *fakeTokens
+	throttle   *fakeThrottle
+	households *fakeHouseholds
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{
+		tokens:     &fakeTokens{keys: map[string]bool{}, notBefore: map[string]int64{}},
+		throttle:   &fakeThrottle{hits: map[string]int64{}},
+		households: &fakeHouseholds{},
+	}
+}
+
+func (s *fakeStorage) Token() storage.ITokenStorage         { return s.tokens }
+func (s *fakeStorage) Throttle() storage.IThrottleStorage   { return s.throttle }
+func (s *fakeStorage) Household() storage.IHouseholdStorage { return s.households }
+
+type fakeTokens struct {
+	mu        sync.Mutex
+	keys      map[string]bool
+	notBefore map[string]int64
+}
+
+func (f *fakeTokens) set(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.keys[key] {
+		return false
+	}
+	f.keys[key] = true
+	return true
+}
+
+func (f *fakeTokens) has(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.keys[key]
+}
+
+func (f *fakeTokens) MarkRefreshTokenUsed(ctx context.Context, jti string, ttl time.Duration) (bool, error) {
+	return f.set("used:" + jti), nil
+}
+
+func (f *fakeTokens) RevokeTokenFamily(ctx context.Context, familyId string, ttl time.Duration) error {
+	f.set("family:" + familyId)
+	return nil
+}
+
+func (f *fakeTokens) IsTokenFamilyRevoked(ctx context.Context, familyId string) (bool, error) {
+	return f.has("family:" + familyId), nil
+}
+
+func (f *fakeTokens) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	f.set("jti:" + jti)
+	return nil
+}
+
+func (f *fakeTokens) RevokeUserTokens(ctx context.Context, userId string, notBefore time.Time, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.notBefore["user:"+userId] = notBefore.Unix()
+	return nil
+}
+
+func (f *fakeTokens) RevokeEmailTokens(ctx context.Context, email string, notBefore time.Time, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.notBefore["email:"+strings.ToLower(email)] = notBefore.Unix()
+	return nil
+}
+
+func (f *fakeTokens) IsTokenRevoked(ctx context.Context, jti, userId, email string, issuedAt time.Time) (bool, error) {
+	if f.has("jti:" + jti) {
+		return true, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, key := range []string{"user:" + userId, "email:" + strings.ToLower(email)} {
+		if notBefore, ok := f.notBefore[key]; ok && issuedAt.Unix() <= notBefore {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeHouseholds has no shared resources
+type fakeHouseholds struct {
+	storage.IHouseholdStorage
+}
+
+func (f *fakeHouseholds) GetResourceHousehold(ctx context.Context, resourceType, resourceId string) (string, error) {
+	return "", storage.ErrNotFound
+}
+
+type fakeThrottle struct {
+	mu   sync.Mutex
+	hits map[string]int64
+}
+
+func (f *fakeThrottle) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.hits[key]++
+	return f.hits[key], nil
+}
+
+func (f *fakeThrottle) Allow(ctx context.Context, key string, limit int64, window time.Duration) (int64, bool, error) {
+	count, _ := f.Hit(ctx, key, window)
+	return count, count <= limit, nil
+}
+
+// fakeServices serves the backend calls of the tests. Calls to the services it does not implement panic.
+type fakeServices struct {
+	client.IServiceManager
+	users    *fakeUsers
+	accounts *fakeAccounts
+}
+
+func (s *fakeServices) UsersService() pbu.UsersServiceClient    { return s.users }
+func (s *fakeServices) AccountService() pb.AccountServiceClient { return s.accounts }
+
+type fakeUsers struct {
+	pbu.UsersServiceClient
+	users      map[string]*pbu.User
+	resetCodes map[string]string
+	// failures is the number of the next calls that fail as if the service were unavailable
+	failures int
+}
+
+func (f *fakeUsers) GetUserProfile(ctx context.Context, in *pbu.PrimaryKey, opts ...grpc.CallOption) (*pbu.User, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, status.Error(codes.Unavailable, "users service is unavailable")
+	}
+	user, ok := f.users[in.Id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return user, nil
+}
+
+func (f *fakeUsers) ResetPassword(ctx context.Context, in *pbu.ResetPasswordReq, opts ...grpc.CallOption) (*pbu.Message, error) {
+	if code, ok := f.resetCodes[in.Email]; !ok || code != in.Code {
+		return nil, status.Error(codes.InvalidArgument, "invalid code")
+	}
+	return &pbu.Message{}, nil
+}
+
+// fakeAccounts keeps accounts by id and records the calls that change them
+type fakeAccounts struct {
+	pb.AccountServiceClient
+	accounts map[string]*pb.Account
+	filter   *pb.AccountFilter
+	updated  []string
+	deleted  []string
+}
+
+func (f *fakeAccounts) GetById(ctx context.Context, in *pb.PrimaryKey, opts ...grpc.CallOption) (*pb.Account, error) {
+	account, ok := f.accounts[in.Id]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "account not found")
+	}
+	return account, nil
+}
+
+func (f *fakeAccounts) GetAll(ctx context.Context, in *pb.AccountFilter, opts ...grpc.CallOption) (*pb.Accounts, error) {
+	f.filter = in
+	return &pb.Accounts{}, nil
+}
+
+func (f *fakeAccounts) Update(ctx context.Context, in *pb.Account, opts ...grpc.CallOption) (*pb.Account, error) {
+	f.updated = append(f.updated, in.Id)
+	return in, nil
+}
+
+func (f *fakeAccounts) Delete(ctx context.Context, in *pb.PrimaryKey, opts ...grpc.CallOption) (*pb.Void, error) {
+	f.deleted = append(f.deleted, in.Id)
+	return &pb.Void{}, nil
+}
@@ -0,0 +1,499 @@
+package v1
+
+import (
+	"api_gateway/api/handlers/models"
+	pb "api_gateway/genproto/budgeting_service"
+	"api_gateway/storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	householdOwner  = "owner"
+	householdEditor = "editor"
+	householdViewer = "viewer"
+)
+
+var householdRoles = map[string]bool{
+	householdOwner:  true,
+	householdEditor: true,
+	householdViewer: true,
+}
+
+// CreateHousehold godoc
+// @Security        ApiKeyAuth
+// @Router          /households/create [post]
+// @Summary         Create household
+// @Description     Creates a household that shares accounts, budgets and goals between its members. The creator becomes its owner.
+// @Tags            households
+// @Accept          json
+// @Produce         json
+// @Param           body body models.CreateHouseholdReq true "Household name"
+// @Success         201 {object} models.Household "Household created successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) CreateHousehold(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	req := models.CreateHouseholdReq{}
+	if err = ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if req.Name == "" {
+		return handleResponse(ctx, h.log, "name is required", http.StatusBadRequest, "name is required")
+	}
+
+	household := models.Household{
+		Id:        uuid.NewString(),
+		Name:      req.Name,
+		OwnerId:   user.Id,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+
+	err = h.storage.Household().Create(ctx.Context(), &household)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while creating household", http.StatusInternalServerError, err.Error())
+	}
+
+	_, err = h.households.AddRoleForUserInDomain(user.Id, householdOwner, household.Id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while adding household owner", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Household successfully created", http.StatusCreated, household)
+}
+
+// GetMyHouseholds godoc
+// @Security        ApiKeyAuth
+// @Router          /households/all [get]
+// @Summary         List households
+// @Description     Lists the households the current user is a member of, with their role in each
+// @Tags            households
+// @Accept          json
+// @Produce         json
+// @Success         200 {object} models.Households "Households retrieved successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) GetMyHouseholds(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	memberships, err := h.households.GetFilteredGroupingPolicy(0, user.Id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting household memberships", http.StatusInternalServerError, err.Error())
+	}
+
+	res := models.Households{Households: []models.HouseholdMembership{}}
+	for _, membership := range memberships {
+		household, err := h.storage.Household().Get(ctx.Context(), membership[2])
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return handleResponse(ctx, h.log, "error while getting household", http.StatusInternalServerError, err.Error())
+		}
+		res.Households = append(res.Households, models.HouseholdMembership{Household: *household, Role: membership[1]})
+	}
+
+	return handleResponse(ctx, h.log, "Households successfully retrieved", http.StatusOK, res)
+}
+
+// GetHouseholdById godoc
+// @Security        ApiKeyAuth
+// @Router          /households/{id} [get]
+// @Summary         Get household
+// @Description     Retrieves a household with its members and shared resources
+// @Tags            households
+// @Accept          json
+// @Produce         json
+// @Param           id path string true "Household ID"
+// @Success         200 {object} models.HouseholdDetails "Household retrieved successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) GetHouseholdById(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	id := ctx.Params("id")
+	role := h.householdRole(user.Id, id)
+	if role == "" && user.Role != adminRole {
+		return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+	}
+
+	household, err := h.storage.Household().Get(ctx.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting household", http.StatusInternalServerError, err.Error())
+	}
+
+	memberships, err := h.households.GetFilteredGroupingPolicy(2, id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting household members", http.StatusInternalServerError, err.Error())
+	}
+	resources, err := h.storage.Household().GetResources(ctx.Context(), id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting shared resources", http.StatusInternalServerError, err.Error())
+	}
+
+	res := models.HouseholdDetails{
+		Household: *household,
+		Role:      role,
+		Members:   make([]models.HouseholdMember, 0, len(memberships)),
+		Resources: resources,
+	}
+	for _, membership := range memberships {
+		res.Members = append(res.Members, models.HouseholdMember{UserId: membership[0], Role: membership[1]})
+	}
+
+	return handleResponse(ctx, h.log, "Household successfully retrieved", http.StatusOK, res)
+}
+
+// InviteHouseholdMember godoc
+// @Security        ApiKeyAuth
+// @Router          /households/{id}/invite [post]
+// @Summary         Invite household member
+// @Description     Creates a single-use invitation token with a role of owner, editor or viewer.
+// @Description     If email is set, only the user with that email can accept it.
+// @Tags            households
+// @Accept          json
+// @Produce         json
+// @Param           id path string true "Household ID"
+// @Param           body body models.InviteHouseholdMemberReq true "Invitee email and role"
+// @Success         201 {object} models.HouseholdInvitation "Invitation created successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) InviteHouseholdMember(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	id := ctx.Params("id")
+	if h.householdRole(user.Id, id) == "" {
+		return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+	}
+	allowed, err := h.households.Enforce(user.Id, id, resourceHousehold, actionManage)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "only household owners can invite members", http.StatusForbidden, "only household owners can invite members")
+	}
+
+	req := models.InviteHouseholdMemberReq{}
+	if err = ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if !householdRoles[req.Role] {
+		return handleResponse(ctx, h.log, "invalid role", http.StatusBadRequest, "role must be owner, editor or viewer")
+	}
+
+	invitation := models.HouseholdInvitation{
+		Token:       uuid.NewString(),
+		HouseholdId: id,
+		Email:       normalizeEmail(req.Email),
+		Role:        req.Role,
+		InvitedBy:   user.Id,
+		ExpiresAt:   time.Now().Add(h.cfg.HouseholdInvitationTTL).Format(time.RFC3339),
+	}
+
+	err = h.storage.Household().CreateInvitation(ctx.Context(), &invitation, h.cfg.HouseholdInvitationTTL)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while creating invitation", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Invitation successfully created", http.StatusCreated, invitation)
+}
+
+// AcceptHouseholdInvitation godoc
+// @Security        ApiKeyAuth
+// @Router          /households/accept [post]
+// @Summary         Accept household invitation
+// @Description     Joins the household of the invitation with the role it was issued for
+// @Tags            households
+// @Accept          json
+// @Produce         json
+// @Param           body body models.AcceptHouseholdInvitationReq true "Invitation token"
+// @Success         200 {object} models.HouseholdMembership "Invitation accepted successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         409 {object} models.Response "Conflict"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) AcceptHouseholdInvitation(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	req := models.AcceptHouseholdInvitationReq{}
+	if err = ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+
+	invitation, err := h.storage.Household().GetInvitation(reqCtx, req.Token)
+	if errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "Invitation not found", http.StatusNotFound, "invitation is invalid or expired")
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting invitation", http.StatusInternalServerError, err.Error())
+	}
+	if invitation.Email != "" && invitation.Email != normalizeEmail(user.Email) {
+		return handleResponse(ctx, h.log, "invitation was issued for another user", http.StatusForbidden, "invitation was issued for another user")
+	}
+	if h.householdRole(user.Id, invitation.HouseholdId) != "" {
+		return handleResponse(ctx, h.log, "already a household member", http.StatusConflict, "already a household member")
+	}
+
+	household, err := h.storage.Household().Get(reqCtx, invitation.HouseholdId)
+	if errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting household", http.StatusInternalServerError, err.Error())
+	}
+
+	err = h.storage.Household().DeleteInvitation(reqCtx, req.Token)
+	if errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "Invitation not found", http.StatusNotFound, "invitation is invalid or expired")
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while using invitation", http.StatusInternalServerError, err.Error())
+	}
+
+	_, err = h.households.AddRoleForUserInDomain(user.Id, invitation.Role, invitation.HouseholdId)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while adding household member", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Invitation successfully accepted", http.StatusOK, models.HouseholdMembership{
+		Household: *household,
+		Role:      invitation.Role,
+	})
+}
+
+// RemoveHouseholdMember godoc
+// @Security        ApiKeyAuth
+// @Router          /households/{id}/members/{user_id} [delete]
+// @Summary         Remove household member
+// @Description     Owners can remove any member, every member can remove themselves. The last owner can not leave.
+// @Tags            households
+// @Accept          json
+// @Produce         json
+// @Param           id path string true "Household ID"
+// @Param           user_id path string true "User ID of the member"
+// @Success         200 {object} models.Response "Member removed successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) RemoveHouseholdMember(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	id := ctx.Params("id")
+	memberId := ctx.Params("user_id")
+	if h.householdRole(user.Id, id) == "" {
+		return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+	}
+
+	if memberId != user.Id {
+		allowed, err := h.households.Enforce(user.Id, id, resourceHousehold, actionManage)
+		if err != nil {
+			return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+		}
+		if !allowed {
+			return handleResponse(ctx, h.log, "only household owners can remove members", http.StatusForbidden, "only household owners can remove members")
+		}
+	}
+
+	role := h.householdRole(memberId, id)
+	if role == "" {
+		return handleResponse(ctx, h.log, "Member not found", http.StatusNotFound, "member not found")
+	}
+	if role == householdOwner && len(h.households.GetUsersForRoleInDomain(householdOwner, id)) <= 1 {
+		return handleResponse(ctx, h.log, "the last owner can not leave the household", http.StatusBadRequest, "household must keep at least one owner")
+	}
+
+	_, err = h.households.DeleteRolesForUserInDomain(memberId, id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while removing household member", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Member successfully removed", http.StatusOK, models.Message{Message: "member removed"})
+}
+
+// ShareHouseholdResource godoc
+// @Security        ApiKeyAuth
+// @Router          /households/{id}/share [post]
+// @Summary         Share resource with household
+// @Description     Shares one of your accounts, budgets or goals with a household where you are an owner or editor.
+// @Description     Members then access it according to their household role.
+// @Tags            households
+// @Accept          json
+// @Produce         json
+// @Param           id path string true "Household ID"
+// @Param           body body models.SharedResource true "Resource type (accounts, budgets, goals) and ID"
+// @Success         201 {object} models.SharedResource "Resource shared successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         409 {object} models.Response "Conflict"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) ShareHouseholdResource(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	id := ctx.Params("id")
+	if h.householdRole(user.Id, id) == "" {
+		return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+	}
+
+	req := models.SharedResource{}
+	if err = ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if !shareableResources[req.Type] {
+		return handleResponse(ctx, h.log, "invalid resource type", http.StatusBadRequest, "type must be accounts, budgets or goals")
+	}
+
+	allowed, err := h.households.Enforce(user.Id, id, req.Type, actionWrite)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "viewers can not share resources", http.StatusForbidden, "viewers can not share resources")
+	}
+
+	resource, err := h.getShareableResource(reqCtx, req.Type, req.ResourceId)
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving resource by ID", err)
+	}
+	if resource.GetUserId() != user.Id {
+		return handleResponse(ctx, h.log, "Resource not found", http.StatusNotFound, "resource not found")
+	}
+
+	shared, err := h.storage.Household().ShareResource(reqCtx, id, req)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while sharing resource", http.StatusInternalServerError, err.Error())
+	}
+	if !shared {
+		return handleResponse(ctx, h.log, "resource is already shared", http.StatusConflict, "resource is already shared with another household")
+	}
+
+	return handleResponse(ctx, h.log, "Resource successfully shared", http.StatusCreated, req)
+}
+
+// UnshareHouseholdResource godoc
+// @Security        ApiKeyAuth
+// @Router          /households/{id}/resources/{type}/{resource_id} [delete]
+// @Summary         Stop sharing resource
+// @Description     Household owners and the owner of the resource can stop sharing it
+// @Tags            households
+// @Accept          json
+// @Produce         json
+// @Param           id path string true "Household ID"
+// @Param           type path string true "Resource type: accounts, budgets or goals"
+// @Param           resource_id path string true "Resource ID"
+// @Success         200 {object} models.Response "Resource unshared successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) UnshareHouseholdResource(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	id := ctx.Params("id")
+	if h.householdRole(user.Id, id) == "" {
+		return handleResponse(ctx, h.log, "Household not found", http.StatusNotFound, "household not found")
+	}
+
+	resource := models.SharedResource{Type: ctx.Params("type"), ResourceId: ctx.Params("resource_id")}
+	if !shareableResources[resource.Type] {
+		return handleResponse(ctx, h.log, "Resource not found", http.StatusNotFound, "resource not found")
+	}
+
+	allowed, err := h.households.Enforce(user.Id, id, resourceHousehold, actionManage)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		owned, err := h.getShareableResource(reqCtx, resource.Type, resource.ResourceId)
+		if err != nil {
+			return handleGrpcError(ctx, h.log, "Error while retrieving resource by ID", err)
+		}
+		if owned.GetUserId() != user.Id {
+			return handleResponse(ctx, h.log, "only household owners and the resource owner can stop sharing it", http.StatusForbidden, "only household owners and the resource owner can stop sharing it")
+		}
+	}
+
+	err = h.storage.Household().UnshareResource(reqCtx, id, resource)
+	if errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "Resource not found", http.StatusNotFound, "resource is not shared with this household")
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while unsharing resource", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Resource successfully unshared", http.StatusOK, models.Message{Message: "resource unshared"})
+}
+
+// householdRole returns the role of userId in the household or "" if they are not a member
+func (h *HandlerV1) householdRole(userId, householdId string) string {
+	roles := h.households.GetRolesForUserInDomain(userId, householdId)
+	if len(roles) == 0 {
+		return ""
+	}
+	return roles[0]
+}
+
+func (h *HandlerV1) getShareableResource(ctx context.Context, resourceType, id string) (ownedResource, error) {
+	req := &pb.PrimaryKey{Id: id}
+
+	switch resourceType {
+	case resourceAccounts:
+		return h.services.AccountService().GetById(ctx, req)
+	case resourceBudgets:
+		return h.services.BudgetService().GetById(ctx, req)
+	case resourceGoals:
+		return h.services.GoalService().GetById(ctx, req)
+	}
+
+	return nil, fmt.Errorf("resource type %q can not be shared", resourceType)
+}
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"api_gateway/api/handlers/middleware"
+	"api_gateway/api/handlers/models"
+	"api_gateway/storage"
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	adminRole = "admin"
+
+	resourceAccounts     = "accounts"
+	resourceBudgets      = "budgets"
+	resourceCategories   = "categories"
+	resourceGoals        = "goals"
+	resourceTransactions = "transactions"
+	resourceHousehold    = "household"
+
+	actionRead   = "read"
+	actionWrite  = "write"
+	actionDelete = "delete"
+	actionManage = "manage"
+)
+
+// shareableResources are the resource types that can be shared with a household
+var shareableResources = map[string]bool{
+	resourceAccounts: true,
+	resourceBudgets:  true,
+	resourceGoals:    true,
+}
+
+// ownedResource is implemented by every budgeting service message that belongs to a user
+type ownedResource interface {
+	GetId() string
+	GetUserId() string
+}
+
+// canAccess reports whether user may perform action on resource. Admins and the owner can do everything,
+// members of the household the resource is shared with are checked against their role in that household.
+func (h *HandlerV1) canAccess(ctx context.Context, user *models.UserInfoFromToken, resourceType string, resource ownedResource, action string) (bool, error) {
+	if user.Role == adminRole || resource.GetUserId() == user.Id {
+		return true, nil
+	}
+	if !shareableResources[resourceType] {
+		return false, nil
+	}
+
+	householdId, err := h.storage.Household().GetResourceHousehold(ctx, resourceType, resource.GetId())
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return h.households.Enforce(user.Id, householdId, resourceType, action)
+}
+
+// userIdFilter returns the user_id a list request is restricted to. Only admins may
+// choose it freely, everybody else always gets their own resources.
+func userIdFilter(ctx *fiber.Ctx, user *models.UserInfoFromToken) string {
+	if user.Role == adminRole {
+		return ctx.Query("user_id")
+	}
+	return user.Id
+}
+
+// householdResources returns the page of the resources of resourceType shared with the household of the
+// household_id query. allowed is false when the user is not a member of the household who may read them.
+func (h *HandlerV1) householdResources(ctx *fiber.Ctx, user *models.UserInfoFromToken, resourceType string) ([]ownedResource, bool, error) {
+	householdId := ctx.Query(middleware.HouseholdQuery)
+	if user.Role != adminRole {
+		allowed, err := h.households.Enforce(user.Id, householdId, resourceType, actionRead)
+		if err != nil || !allowed {
+			return nil, false, err
+		}
+	}
+
+	shared, err := h.storage.Household().GetResources(ctx.Context(), householdId)
+	if err != nil {
+		return nil, true, err
+	}
+
+	var ids []string
+	for _, resource := range shared {
+		if resource.Type == resourceType {
+			ids = append(ids, resource.ResourceId)
+		}
+	}
+	// the set has no order, sorting keeps the pages stable
+	sort.Strings(ids)
+
+	page, limit := ctx.QueryInt("page", 1), ctx.QueryInt("limit", 10)
+	if page < 1 || limit < 1 {
+		return nil, true, nil
+	}
+	start := min((page-1)*limit, len(ids))
+	end := min(start+limit, len(ids))
+
+	resources := make([]ownedResource, 0, end-start)
+	for _, id := range ids[start:end] {
+		resource, err := h.getShareableResource(ctx.Context(), resourceType, id)
+		if status.Code(err) == codes.NotFound {
+			// deleted after it was shared
+			continue
+		}
+		if err != nil {
+			return nil, true, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, true, nil
+}
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"api_gateway/api/handlers/middleware"
+	pb "api_gateway/genproto/budgeting_service"
+	"api_gateway/pkg/jwt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newOwnershipApp(h *HandlerV1, claims *jwt.Claims) *fiber.App {
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals(middleware.ClaimsKey, claims)
+		return ctx.Next()
+	})
+	app.Get("/accounts/all", h.GetAllAccounts)
+	app.Get("/accounts/:id", h.GetAccountById)
+	app.Put("/accounts/:id/update", h.UpdateAccount)
+	app.Delete("/accounts/:id/delete", h.DeleteAccount)
+
+	return app
+}
+
+func TestAccountOwnership(t *testing.T) {
+	owner := &jwt.Claims{UserId: "owner", Role: "user"}
+	other := &jwt.Claims{UserId: "other", Role: "user"}
+	admin := &jwt.Claims{UserId: "admin", Role: "admin"}
+
+	tests := []struct {
+		name        string
+		claims      *jwt.Claims
+		method      string
+		path        string
+		wantStatus  int
+		wantUpdated int
+		wantDeleted int
+	}{
+		{"the owner reads", owner, http.MethodGet, "/accounts/account-1", http.StatusOK, 0, 0},
+		{"another user can not read", other, http.MethodGet, "/accounts/account-1", http.StatusNotFound, 0, 0},
+		{"an admin reads", admin, http.MethodGet, "/accounts/account-1", http.StatusOK, 0, 0},
+		{"the owner updates", owner, http.MethodPut, "/accounts/account-1/update", http.StatusOK, 1, 0},
+		{"another user can not update", other, http.MethodPut, "/accounts/account-1/update", http.StatusNotFound, 0, 0},
+		{"the owner deletes", owner, http.MethodDelete, "/accounts/account-1/delete", http.StatusOK, 0, 1},
+		{"another user can not delete", other, http.MethodDelete, "/accounts/account-1/delete", http.StatusNotFound, 0, 0},
+		{"an admin deletes", admin, http.MethodDelete, "/accounts/account-1/delete", http.StatusOK, 0, 1},
+		{"a missing account is not found", owner, http.MethodGet, "/accounts/account-2", http.StatusNotFound, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := &fakeAccounts{accounts: map[string]*pb.Account{
+				"account-1": {Id: "account-1", UserId: "owner", Name: "Savings"},
+			}}
+			h := newTestHandler(&fakeServices{accounts: accounts}, newFakeStorage())
+
+			status, body := request(t, newOwnershipApp(h, tt.claims), tt.method, tt.path, `{"name":"Renamed"}`, nil)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", status, tt.wantStatus, body)
+			}
+			if len(accounts.updated) != tt.wantUpdated || len(accounts.deleted) != tt.wantDeleted {
+				t.Errorf("updated %v and deleted %v, want %d and %d calls", accounts.updated, accounts.deleted, tt.wantUpdated, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestAccountListUserFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *jwt.Claims
+		path   string
+		want   string
+	}{
+		{"a user lists their own accounts", &jwt.Claims{UserId: "owner", Role: "user"}, "/accounts/all", "owner"},
+		{"a user can not list the accounts of another user", &jwt.Claims{UserId: "owner", Role: "user"}, "/accounts/all?user_id=other", "owner"},
+		{"an admin lists the accounts of another user", &jwt.Claims{UserId: "admin", Role: "admin"}, "/accounts/all?user_id=other", "other"},
+		{"an admin lists all accounts", &jwt.Claims{UserId: "admin", Role: "admin"}, "/accounts/all", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := &fakeAccounts{}
+			h := newTestHandler(&fakeServices{accounts: accounts}, newFakeStorage())
+
+			if status, body := request(t, newOwnershipApp(h, tt.claims), http.MethodGet, tt.path, "", nil); status != http.StatusOK {
+				t.Fatalf("status = %d, body %s", status, body)
+			}
+			if accounts.filter.UserId != tt.want {
+				t.Errorf("user_id filter = %q, want %q", accounts.filter.UserId, tt.want)
+			}
+		})
+	}
+}
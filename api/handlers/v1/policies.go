@@ -0,0 +1,220 @@
+package v1
+
+import (
+	"api_gateway/api/handlers/models"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPolicies godoc
+// @Security        ApiKeyAuth
+// @Router          /admin/policies [get]
+// @Summary         List policies
+// @Description     Lists the Casbin policies and role assignments the gateway enforces
+// @Tags            admin
+// @Accept          json
+// @Produce         json
+// @Success         200 {object} models.Policies "Policies retrieved successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) GetPolicies(ctx *fiber.Ctx) error {
+	policies, err := h.enforcer.GetPolicy()
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting policies", http.StatusInternalServerError, err.Error())
+	}
+	roles, err := h.enforcer.GetGroupingPolicy()
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting role assignments", http.StatusInternalServerError, err.Error())
+	}
+
+	res := models.Policies{
+		Policies: make([]models.Policy, 0, len(policies)),
+		Roles:    make([]models.RoleAssignment, 0, len(roles)),
+	}
+	for _, p := range policies {
+		if len(p) < 3 {
+			continue
+		}
+		res.Policies = append(res.Policies, models.Policy{Subject: p[0], Object: p[1], Action: p[2]})
+	}
+	for _, g := range roles {
+		if len(g) < 2 {
+			continue
+		}
+		res.Roles = append(res.Roles, models.RoleAssignment{Subject: g[0], Role: g[1]})
+	}
+
+	return handleResponse(ctx, h.log, "Policies successfully retrieved", http.StatusOK, res)
+}
+
+// AddPolicy godoc
+// @Security        ApiKeyAuth
+// @Router          /admin/policies [post]
+// @Summary         Add policy
+// @Description     Adds a policy, persists it and propagates it to all gateway replicas
+// @Tags            admin
+// @Accept          json
+// @Produce         json
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
+// @Param           body body models.Policy true "Subject, object and action of the policy"
+// @Success         201 {object} models.Policy "Policy added successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         409 {object} models.Response "Conflict"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) AddPolicy(ctx *fiber.Ctx) error {
+	req := models.Policy{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if !validPolicyValues(req.Subject, req.Object, req.Action) {
+		return handleResponse(ctx, h.log, "invalid policy", http.StatusBadRequest, "subject, object and action are required and must not contain commas or quotes")
+	}
+
+	// casbin reports an existing rule as added, so it has to be looked up first
+	exists, err := h.enforcer.HasPolicy(req.Subject, req.Object, req.Action)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while adding policy", http.StatusInternalServerError, err.Error())
+	}
+	if exists {
+		return handleResponse(ctx, h.log, "policy already exists", http.StatusConflict, "policy already exists")
+	}
+
+	_, err = h.enforcer.AddPolicy(req.Subject, req.Object, req.Action)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while adding policy", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Policy successfully added", http.StatusCreated, req)
+}
+
+// RemovePolicy godoc
+// @Security        ApiKeyAuth
+// @Router          /admin/policies [delete]
+// @Summary         Remove policy
+// @Description     Removes a policy, persists the change and propagates it to all gateway replicas
+// @Tags            admin
+// @Accept          json
+// @Produce         json
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
+// @Param           body body models.Policy true "Subject, object and action of the policy"
+// @Success         200 {object} models.Response "Policy removed successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) RemovePolicy(ctx *fiber.Ctx) error {
+	req := models.Policy{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if !validPolicyValues(req.Subject, req.Object, req.Action) {
+		return handleResponse(ctx, h.log, "invalid policy", http.StatusBadRequest, "subject, object and action are required and must not contain commas or quotes")
+	}
+
+	removed, err := h.enforcer.RemovePolicy(req.Subject, req.Object, req.Action)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while removing policy", http.StatusInternalServerError, err.Error())
+	}
+	if !removed {
+		return handleResponse(ctx, h.log, "policy not found", http.StatusNotFound, "policy not found")
+	}
+
+	return handleResponse(ctx, h.log, "Policy successfully removed", http.StatusOK, models.Message{Message: "policy removed"})
+}
+
+// AddRoleAssignment godoc
+// @Security        ApiKeyAuth
+// @Router          /admin/policies/roles [post]
+// @Summary         Assign role
+// @Description     Makes subject inherit every policy of role, e.g. subject "support" and role "user"
+// @Tags            admin
+// @Accept          json
+// @Produce         json
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
+// @Param           body body models.RoleAssignment true "Subject and the role it gets"
+// @Success         201 {object} models.RoleAssignment "Role assigned successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         409 {object} models.Response "Conflict"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) AddRoleAssignment(ctx *fiber.Ctx) error {
+	req := models.RoleAssignment{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if !validPolicyValues(req.Subject, req.Role) {
+		return handleResponse(ctx, h.log, "invalid role assignment", http.StatusBadRequest, "subject and role are required and must not contain commas or quotes")
+	}
+
+	exists, err := h.enforcer.HasGroupingPolicy(req.Subject, req.Role)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while assigning role", http.StatusInternalServerError, err.Error())
+	}
+	if exists {
+		return handleResponse(ctx, h.log, "role assignment already exists", http.StatusConflict, "role assignment already exists")
+	}
+
+	_, err = h.enforcer.AddGroupingPolicy(req.Subject, req.Role)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while assigning role", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Role successfully assigned", http.StatusCreated, req)
+}
+
+// RemoveRoleAssignment godoc
+// @Security        ApiKeyAuth
+// @Router          /admin/policies/roles [delete]
+// @Summary         Unassign role
+// @Description     Removes a role assignment, persists the change and propagates it to all gateway replicas
+// @Tags            admin
+// @Accept          json
+// @Produce         json
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
+// @Param           body body models.RoleAssignment true "Subject and the role it loses"
+// @Success         200 {object} models.Response "Role assignment removed successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         403 {object} models.Response "Forbidden"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) RemoveRoleAssignment(ctx *fiber.Ctx) error {
+	req := models.RoleAssignment{}
+	if err := ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if !validPolicyValues(req.Subject, req.Role) {
+		return handleResponse(ctx, h.log, "invalid role assignment", http.StatusBadRequest, "subject and role are required and must not contain commas or quotes")
+	}
+
+	removed, err := h.enforcer.RemoveGroupingPolicy(req.Subject, req.Role)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while removing role assignment", http.StatusInternalServerError, err.Error())
+	}
+	if !removed {
+		return handleResponse(ctx, h.log, "role assignment not found", http.StatusNotFound, "role assignment not found")
+	}
+
+	return handleResponse(ctx, h.log, "Role assignment successfully removed", http.StatusOK, models.Message{Message: "role assignment removed"})
+}
+
+// validPolicyValues reports whether values can be stored as a csv policy line and read back unchanged
+func validPolicyValues(values ...string) bool {
+	for _, v := range values {
+		if v == "" || v != strings.TrimSpace(v) || strings.ContainsAny(v, ",\"\n\r") {
+			return false
+		}
+	}
+	return true
+}
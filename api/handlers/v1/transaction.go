@@ -1,7 +1,9 @@
 package v1
 
 import (
+	"api_gateway/api/handlers/middleware"
 	pb "api_gateway/genproto/budgeting_service"
+	"encoding/json"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,28 +17,65 @@ import (
 // @Accept          json
 // @Produce         json
 // @Param           body body budgeting_service.CreateTransaction true "Transaction Creation Request"
+// @Param           Idempotency-Key header string false "Unique key of the request, a retry with the same key returns the first response"
 // @Success         201 {object} budgeting_service.Transaction "Transaction created successfully"
+// @Failure         400 {object} models.Response "Bad Request"
 // @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         404 {object} models.Response "Not Found"
+// @Failure         409 {object} models.Response "Request with the same Idempotency-Key is in progress"
+// @Failure         422 {object} models.Response "Idempotency-Key was used for a different request"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) CreateTransaction(ctx *fiber.Ctx) error {
-	// reqCtx := ctx.Context()
+	reqCtx := ctx.Context()
 
-	// req := pb.CreateTransaction{}
-	// err := ctx.BodyParser(&req)
-	// if err != nil {
-	// 	return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
-	// }
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
+	req := pb.CreateTransaction{}
+	err = ctx.BodyParser(&req)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
+	}
+	req.UserId = user.Id
 
-	err := h.iKafka.ProduceMessage("transaction_created", string(ctx.Body()))
+	account, err := h.services.AccountService().GetById(reqCtx, &pb.PrimaryKey{Id: req.AccountId})
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while sending message", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving account by ID", err)
+	}
+	allowed, err := h.canAccess(reqCtx, user, resourceAccounts, account, actionWrite)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Account not found", http.StatusNotFound, "account not found")
 	}
+	middleware.InvalidateCacheOf(ctx, account.UserId)
 
-	// res, err := h.services.TransactionService().Create(reqCtx, &req)
-	// if err != nil {
-	// 	return handleResponse(ctx, h.log, "Error while creating transaction", http.StatusInternalServerError, err.Error())
-	// }
+	if req.CategoryId != "" {
+		category, err := h.services.CategoryService().GetById(reqCtx, &pb.PrimaryKey{Id: req.CategoryId})
+		if err != nil {
+			return handleGrpcError(ctx, h.log, "Error while retrieving category by ID", err)
+		}
+		allowed, err := h.canAccess(reqCtx, user, resourceCategories, category, actionRead)
+		if err != nil {
+			return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+		}
+		if !allowed {
+			return handleResponse(ctx, h.log, "Category not found", http.StatusNotFound, "category not found")
+		}
+	}
+
+	data, err := json.Marshal(&req)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while marshalling request", http.StatusInternalServerError, err.Error())
+	}
+
+	err = h.iKafka.ProduceMessage(ctx.Context(), "transaction_created", string(data))
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while sending message", http.StatusInternalServerError, err.Error())
+	}
 
 	return handleResponse(ctx, h.log, "Transaction successfully created", http.StatusCreated, nil)
 }
@@ -54,6 +93,11 @@ func (h *HandlerV1) CreateTransaction(ctx *fiber.Ctx) error {
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetTransactionById(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	id := ctx.Params("id")
 	if len(id) < 5 {
 		return handleResponse(ctx, h.log, "Invalid id", http.StatusBadRequest, nil)
@@ -62,7 +106,14 @@ func (h *HandlerV1) GetTransactionById(ctx *fiber.Ctx) error {
 	req := &pb.PrimaryKey{Id: id}
 	res, err := h.services.TransactionService().GetById(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving transaction by ID", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving transaction by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceTransactions, res, actionRead)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Transaction not found", http.StatusNotFound, "transaction not found")
 	}
 
 	return handleResponse(ctx, h.log, "Transaction successfully retrieved", http.StatusOK, res)
@@ -77,17 +128,22 @@ func (h *HandlerV1) GetTransactionById(ctx *fiber.Ctx) error {
 // @Produce         json
 // @Param           page query int true "Page number"
 // @Param           limit query int true "Limit number"
-// @Param           user_id query string false "User ID"
+// @Param           user_id query string false "User ID, only admins can filter by another user"
 // @Param           amount query float64 false "Amount"
 // @Param           type query string false "Type"
 // @Success         200 {object} budgeting_service.Transactions "Transactions retrieved successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) GetAllTransactions(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
 	req := &pb.TransactionFilter{}
 	req.Page = int32(ctx.QueryInt("page", 1))
 	req.Limit = int32(ctx.QueryInt("limit", 10))
-	req.UserId = ctx.Query("user_id")
+	req.UserId = userIdFilter(ctx, user)
 	if amount := ctx.QueryInt("amount", 0); amount > 0 {
 		req.Amount = float64(amount)
 	}
@@ -95,7 +151,7 @@ func (h *HandlerV1) GetAllTransactions(ctx *fiber.Ctx) error {
 
 	res, err := h.services.TransactionService().GetAll(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while retrieving transactions", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while retrieving transactions", err)
 	}
 
 	return handleResponse(ctx, h.log, "Transactions successfully retrieved", http.StatusOK, res)
@@ -116,6 +172,10 @@ func (h *HandlerV1) GetAllTransactions(ctx *fiber.Ctx) error {
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) UpdateTransaction(ctx *fiber.Ctx) error {
 	reqCtx := ctx.Context()
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
 	id := ctx.Params("id")
 	if len(id) < 5 {
@@ -123,15 +183,65 @@ func (h *HandlerV1) UpdateTransaction(ctx *fiber.Ctx) error {
 	}
 
 	req := pb.Transaction{}
-	err := ctx.BodyParser(&req)
+	err = ctx.BodyParser(&req)
 	if err != nil {
 		return handleResponse(ctx, h.log, "Error while parsing body", http.StatusBadRequest, err.Error())
 	}
 	req.Id = id
 
+	transaction, err := h.services.TransactionService().GetById(reqCtx, &pb.PrimaryKey{Id: id})
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving transaction by ID", err)
+	}
+	allowed, err := h.canAccess(reqCtx, user, resourceTransactions, transaction, actionWrite)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Transaction not found", http.StatusNotFound, "transaction not found")
+	}
+	req.UserId = transaction.UserId
+	middleware.InvalidateCacheOf(ctx, transaction.UserId)
+
+	// moving the transaction needs the same access to the new account and category as creating it
+	if req.AccountId == "" {
+		req.AccountId = transaction.AccountId
+	}
+	if req.AccountId != transaction.AccountId {
+		account, err := h.services.AccountService().GetById(reqCtx, &pb.PrimaryKey{Id: req.AccountId})
+		if err != nil {
+			return handleGrpcError(ctx, h.log, "Error while retrieving account by ID", err)
+		}
+		allowed, err := h.canAccess(reqCtx, user, resourceAccounts, account, actionWrite)
+		if err != nil {
+			return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+		}
+		if !allowed {
+			return handleResponse(ctx, h.log, "Account not found", http.StatusNotFound, "account not found")
+		}
+		middleware.InvalidateCacheOf(ctx, account.UserId)
+	}
+
+	if req.CategoryId == "" {
+		req.CategoryId = transaction.CategoryId
+	}
+	if req.CategoryId != "" && req.CategoryId != transaction.CategoryId {
+		category, err := h.services.CategoryService().GetById(reqCtx, &pb.PrimaryKey{Id: req.CategoryId})
+		if err != nil {
+			return handleGrpcError(ctx, h.log, "Error while retrieving category by ID", err)
+		}
+		allowed, err := h.canAccess(reqCtx, user, resourceCategories, category, actionRead)
+		if err != nil {
+			return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+		}
+		if !allowed {
+			return handleResponse(ctx, h.log, "Category not found", http.StatusNotFound, "category not found")
+		}
+	}
+
 	res, err := h.services.TransactionService().Update(reqCtx, &req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while updating transaction", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while updating transaction", err)
 	}
 
 	return handleResponse(ctx, h.log, "Transaction successfully updated", http.StatusOK, res)
@@ -145,17 +255,37 @@ func (h *HandlerV1) UpdateTransaction(ctx *fiber.Ctx) error {
 // @Accept          json
 // @Produce         json
 // @Param           id path string true "Transaction ID"
+// @Param           X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param           X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
 // @Success         200 {string} string  "Transaction deleted successfully"
 // @Failure         401 {object} models.Response "Unauthorized"
 // @Failure         404 {object} models.Response "Not Found"
 // @Failure         500 {object} models.Response "Internal Server Error"
 func (h *HandlerV1) DeleteTransaction(ctx *fiber.Ctx) error {
-	id := ctx.Params("id")
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
 
+	id := ctx.Params("id")
 	req := &pb.PrimaryKey{Id: id}
-	_, err := h.services.TransactionService().Delete(ctx.Context(), req)
+
+	transaction, err := h.services.TransactionService().GetById(ctx.Context(), req)
+	if err != nil {
+		return handleGrpcError(ctx, h.log, "Error while retrieving transaction by ID", err)
+	}
+	allowed, err := h.canAccess(ctx.Context(), user, resourceTransactions, transaction, actionDelete)
+	if err != nil {
+		return handleResponse(ctx, h.log, "Error while checking access", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "Transaction not found", http.StatusNotFound, "transaction not found")
+	}
+	middleware.InvalidateCacheOf(ctx, transaction.UserId)
+
+	_, err = h.services.TransactionService().Delete(ctx.Context(), req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "Error while deleting transaction", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "Error while deleting transaction", err)
 	}
 
 	return handleResponse(ctx, h.log, "Transaction successfully deleted", http.StatusOK, nil)
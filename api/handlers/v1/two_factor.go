@@ -0,0 +1,234 @@
+package v1
+
+import (
+	"api_gateway/api/handlers/middleware"
+	"api_gateway/api/handlers/models"
+	"api_gateway/pkg/jwt"
+	"api_gateway/pkg/totp"
+	"api_gateway/storage"
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const recoveryCodesCount = 10
+
+// EnrollTOTP godoc
+// @Security        ApiKeyAuth
+// @Router          /users/2fa/enroll [post]
+// @Summary         Start TOTP enrollment
+// @Description     Generates a TOTP secret for an authenticator app. Two-factor authentication is enabled only after /users/2fa/verify.
+// @Tags            2fa
+// @Accept          json
+// @Produce         json
+// @Success         200 {object} models.TOTPEnrollment "TOTP secret generated successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         409 {object} models.Response "Conflict"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) EnrollTOTP(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	_, err = h.storage.TwoFactor().GetSecret(reqCtx, user.Id)
+	if err == nil {
+		return handleResponse(ctx, h.log, "two-factor authentication is already enabled", http.StatusConflict, "two-factor authentication is already enabled")
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "error while getting two-factor settings", http.StatusInternalServerError, err.Error())
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while generating TOTP secret", http.StatusInternalServerError, err.Error())
+	}
+
+	err = h.storage.TwoFactor().SetPendingSecret(reqCtx, user.Id, secret, h.cfg.TOTPEnrollmentTTL)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while saving TOTP secret", http.StatusInternalServerError, err.Error())
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Username
+	}
+	if account == "" {
+		account = user.Id
+	}
+
+	return handleResponse(ctx, h.log, "TOTP enrollment started", http.StatusOK, models.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURL: totp.URL(h.cfg.TOTPIssuer, account, secret),
+	})
+}
+
+// VerifyTOTP godoc
+// @Security        ApiKeyAuth
+// @Router          /users/2fa/verify [post]
+// @Summary         Finish TOTP enrollment
+// @Description     Enables two-factor authentication with the first code from the authenticator app and returns one-time recovery codes.
+// @Description     The recovery codes are shown only once.
+// @Tags            2fa
+// @Accept          json
+// @Produce         json
+// @Param           body body models.TOTPCodeReq true "Code from the authenticator app"
+// @Success         200 {object} models.RecoveryCodes "Two-factor authentication enabled successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         429 {object} models.Response "Too Many Requests"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) VerifyTOTP(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	req := models.TOTPCodeReq{}
+	if err = ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+
+	secret, err := h.storage.TwoFactor().GetPendingSecret(reqCtx, user.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "no pending TOTP enrollment", http.StatusBadRequest, "start the enrollment with /users/2fa/enroll first")
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting pending TOTP secret", http.StatusInternalServerError, err.Error())
+	}
+
+	allowed, err := middleware.AllowOTPAttempt(reqCtx, h.storage.Throttle(), user.Id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while throttling one-time password attempts", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "too many one-time password attempts", http.StatusTooManyRequests, "too many attempts, try again later")
+	}
+
+	ok, err := middleware.VerifyOTP(reqCtx, h.storage.TwoFactor(), user.Id, secret, req.Code)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while verifying one-time password", http.StatusInternalServerError, err.Error())
+	}
+	if !ok {
+		return handleResponse(ctx, h.log, "invalid one-time password", http.StatusBadRequest, "invalid code")
+	}
+
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while generating recovery codes", http.StatusInternalServerError, err.Error())
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, totp.HashRecoveryCode(code))
+	}
+
+	err = h.storage.TwoFactor().Enable(reqCtx, user.Id, secret, hashes)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while enabling two-factor authentication", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Two-factor authentication successfully enabled", http.StatusOK, models.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// StepUpTOTP godoc
+// @Security        ApiKeyAuth
+// @Router          /users/2fa/step-up [post]
+// @Summary         Step up with second factor
+// @Description     Exchanges a TOTP code or a recovery code for a short-lived token that unlocks sensitive routes.
+// @Description     Send it in the X-Step-Up-Token header.
+// @Tags            2fa
+// @Accept          json
+// @Produce         json
+// @Param           body body models.StepUpReq true "TOTP code or recovery code"
+// @Success         200 {object} models.StepUpToken "Step-up token issued successfully"
+// @Failure         400 {object} models.Response "Bad Request"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         429 {object} models.Response "Too Many Requests"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) StepUpTOTP(ctx *fiber.Ctx) error {
+	reqCtx := ctx.Context()
+
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	req := models.StepUpReq{}
+	if err = ctx.BodyParser(&req); err != nil {
+		return handleResponse(ctx, h.log, "error while getting request body", http.StatusBadRequest, err.Error())
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return handleResponse(ctx, h.log, "code is required", http.StatusBadRequest, "code or recovery_code is required")
+	}
+
+	secret, err := h.storage.TwoFactor().GetSecret(reqCtx, user.Id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return handleResponse(ctx, h.log, "two-factor authentication is not enabled", http.StatusBadRequest, "two-factor authentication is not enabled")
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting two-factor settings", http.StatusInternalServerError, err.Error())
+	}
+
+	allowed, err := middleware.AllowOTPAttempt(reqCtx, h.storage.Throttle(), user.Id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while throttling one-time password attempts", http.StatusInternalServerError, err.Error())
+	}
+	if !allowed {
+		return handleResponse(ctx, h.log, "too many one-time password attempts", http.StatusTooManyRequests, "too many attempts, try again later")
+	}
+
+	var ok bool
+	if req.Code != "" {
+		ok, err = middleware.VerifyOTP(reqCtx, h.storage.TwoFactor(), user.Id, secret, req.Code)
+	} else {
+		ok, err = h.storage.TwoFactor().UseRecoveryCode(reqCtx, user.Id, totp.HashRecoveryCode(req.RecoveryCode))
+	}
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while verifying second factor", http.StatusInternalServerError, err.Error())
+	}
+	if !ok {
+		return handleError(ctx, h.log, "invalid second factor", models.CodeAuthOTPInvalid, "invalid code")
+	}
+
+	token, err := jwt.GenerateStepUpToken(h.cfg, user.Id, user.Role)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while generating step-up token", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Step-up token successfully issued", http.StatusOK, models.StepUpToken{
+		StepUpToken: token,
+		ExpiresIn:   int64(h.cfg.StepUpTokenTTL.Seconds()),
+	})
+}
+
+// DisableTOTP godoc
+// @Security        ApiKeyAuth
+// @Router          /users/2fa [delete]
+// @Summary         Disable TOTP
+// @Description     Disables two-factor authentication. Requires a step-up proof in the X-OTP or X-Step-Up-Token header.
+// @Tags            2fa
+// @Accept          json
+// @Produce         json
+// @Param           X-OTP header string false "Current TOTP code"
+// @Param           X-Step-Up-Token header string false "Step-up token"
+// @Success         200 {object} models.Response "Two-factor authentication disabled successfully"
+// @Failure         401 {object} models.Response "Unauthorized"
+// @Failure         500 {object} models.Response "Internal Server Error"
+func (h *HandlerV1) DisableTOTP(ctx *fiber.Ctx) error {
+	user, err := getUserInfoFromToken(ctx)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while getting user info from token", http.StatusUnauthorized, err.Error())
+	}
+
+	err = h.storage.TwoFactor().Disable(ctx.Context(), user.Id)
+	if err != nil {
+		return handleResponse(ctx, h.log, "error while disabling two-factor authentication", http.StatusInternalServerError, err.Error())
+	}
+
+	return handleResponse(ctx, h.log, "Two-factor authentication successfully disabled", http.StatusOK, models.Message{Message: "two-factor authentication disabled"})
+}
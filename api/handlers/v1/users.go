@@ -29,7 +29,7 @@ func (h *HandlerV1) GetUserProfile(ctx *fiber.Ctx) error {
 
 	resp, err := h.services.UsersService().GetUserProfile(reqCtx, &pb.PrimaryKey{Id: user.Id})
 	if err != nil {
-		return handleResponse(ctx, h.log, "error while using GetUserProfile method of users service", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "error while using GetUserProfile method of users service", err)
 	}
 
 	return handleResponse(ctx, h.log, "", http.StatusOK, resp)
@@ -64,7 +64,7 @@ func (h *HandlerV1) UpdateUserProfile(ctx *fiber.Ctx) error {
 
 	resp, err := h.services.UsersService().UpdateUserProfile(reqCtx, &req)
 	if err != nil {
-		return handleResponse(ctx, h.log, "error while using UpdateUserProfile method of users service", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "error while using UpdateUserProfile method of users service", err)
 	}
 
 	return handleResponse(ctx, h.log, "", http.StatusOK, resp)
@@ -79,6 +79,8 @@ func (h *HandlerV1) UpdateUserProfile(ctx *fiber.Ctx) error {
 // @Accept 			json
 // @Produce 		json
 // @Param 			change_password body models.ChangePassword true "change_password"
+// @Param 			X-OTP header string false "Current TOTP code, required if two-factor authentication is enabled"
+// @Param 			X-Step-Up-Token header string false "Step-up token, alternative to X-OTP"
 // @Success 		200  {object}  models.Response
 // @Failure 		400  {object}  models.Response
 // @Failure 		500  {object}  models.Response
@@ -102,7 +104,7 @@ func (h *HandlerV1) ChangePassword(ctx *fiber.Ctx) error {
 		UserId:          user.Id,
 	})
 	if err != nil {
-		return handleResponse(ctx, h.log, "error while using ChangePassword method of users service", http.StatusInternalServerError, err.Error())
+		return handleGrpcError(ctx, h.log, "error while using ChangePassword method of users service", err)
 	}
 
 	return handleResponse(ctx, h.log, "", http.StatusOK, resp)
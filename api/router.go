@@ -6,15 +6,22 @@ package api
 
 import (
 	_ "api_gateway/api/docs"
+	"api_gateway/api/handlers/health"
 	"api_gateway/api/handlers/middleware"
+	"api_gateway/api/handlers/proxy"
 	v1 "api_gateway/api/handlers/v1"
+	"api_gateway/configs"
 	"api_gateway/grpc/client"
+	"api_gateway/pkg/jwt"
 	"api_gateway/pkg/logger"
 	"api_gateway/pkg/messege_brokers/kafka"
+	"api_gateway/pkg/metrics"
+	"api_gateway/storage"
 	"time"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/segmentio/encoding/json"
 	swagger "github.com/swaggo/fiber-swagger"
@@ -29,76 +36,145 @@ import (
 // @in header
 // @name Authorization
 
-func NewRouter(log logger.ILogger, services client.IServiceManager, iKafka kafka.IKafka, casbinEnforcer *casbin.Enforcer) *fiber.App {
-	handlerV1 := v1.NewHandlerV1(services, log, iKafka)
+// @securityDefinitions.apikey XApiKey
+// @in header
+// @name X-API-Key
+
+func NewRouter(log logger.ILogger, services client.IServiceManager, iKafka kafka.IKafka, casbinEnforcer, householdEnforcer *casbin.SyncedEnforcer, storage storage.IStorage, cfg *configs.Config, verifier *jwt.Verifier, authProxy *proxy.AuthProxy, checker *health.Checker, rateLimiter *middleware.RateLimiter, responseCache *middleware.ResponseCache) *fiber.App {
+	handlerV1 := v1.NewHandlerV1(services, log, iKafka, storage, cfg, verifier, casbinEnforcer, householdEnforcer)
 
+	// ctx.IP() reads the client address from ProxyHeader only when the request comes from one of the
+	// trusted proxies, otherwise anyone could choose the address the rate limits count against. Fiber
+	// takes the first valid address of the header, so the proxy must overwrite it rather than append.
 	router := fiber.New(fiber.Config{
-		JSONEncoder: json.Marshal,
-		JSONDecoder: json.Unmarshal,
+		JSONEncoder:             json.Marshal,
+		JSONDecoder:             json.Unmarshal,
+		ErrorHandler:            middleware.ErrorHandler,
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	cors := cors.New(cors.Config{
 		AllowOrigins:  "*",
 		AllowMethods:  "GET,POST,PUT,DELETE",
-		AllowHeaders:  "Authorization",
-		ExposeHeaders: "Authorization",
+		AllowHeaders:  "Authorization, X-OTP, X-Step-Up-Token, X-Response-Format, Idempotency-Key, Cache-Control, X-Request-ID, traceparent, tracestate, baggage",
+		ExposeHeaders: "Authorization, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, Idempotent-Replayed, X-Cache, X-Request-ID",
 		MaxAge:        12 * int(time.Hour),
 	})
 
+	router.Use(middleware.RequestID(log))
+	router.Use(middleware.Tracing(log))
+	router.Use(middleware.Metrics)
 	router.Use(cors)
+	router.Use(middleware.ResponseFormat(cfg.ResponseFormat))
 
 	router.Get("/swagger/*", swagger.WrapHandler)
+	router.Get(cfg.MetricsPath, adaptor.HTTPHandler(metrics.Handler()))
+	router.Get("/healthz", checker.Liveness)
+	router.Get("/readyz", checker.Readiness)
+
+	rateLimit := rateLimiter.Handler
+
+	auth := router.Group("/auth", rateLimit)
+	{
+		// the gateway issues HS256 access tokens, which it rejects itself without JWT_ALLOW_HMAC.
+		// The auth service that signs with the JWKS keys rotates its tokens then.
+		if cfg.JWTAllowHMAC {
+			auth.Post("/refresh", handlerV1.RefreshToken)
+		}
+		auth.Post("/forgot-password", handlerV1.ForgotPassword)
+		auth.Post("/reset-password", handlerV1.ResetPassword)
+		auth.Post("/logout", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), handlerV1.Logout)
+		auth.Post("/logout-all", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), handlerV1.LogoutAll)
+
+		// everything else under /auth (register, login, ...) is served by the user service
+		auth.All("/*", authProxy.Handler)
+	}
+
+	stepUp := middleware.StepUp(storage, verifier, log)
+	idempotency := middleware.Idempotency(storage, cfg.IdempotencyTTL, cfg.IdempotencyLockTTL, log)
 
-	users := router.Group("/users", middleware.JWTMiddleware(casbinEnforcer))
+	users := router.Group("/users", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), rateLimit)
 	{
 		users.Get("/profile", handlerV1.GetUserProfile)
 		users.Put("/update", handlerV1.UpdateUserProfile)
-		users.Put("/password", handlerV1.ChangePassword)
+		users.Put("/password", stepUp, handlerV1.ChangePassword)
+		users.Post("/api-keys", handlerV1.CreateAPIKey)
+		users.Get("/api-keys", handlerV1.GetAPIKeys)
+		users.Delete("/api-keys/:id", handlerV1.RevokeAPIKey)
+		users.Post("/2fa/enroll", handlerV1.EnrollTOTP)
+		users.Post("/2fa/verify", handlerV1.VerifyTOTP)
+		users.Post("/2fa/step-up", handlerV1.StepUpTOTP)
+		users.Delete("/2fa", stepUp, handlerV1.DisableTOTP)
 	}
 
-	accounts := router.Group("/accounts", middleware.JWTMiddleware(casbinEnforcer))
+	accounts := router.Group("/accounts", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), rateLimit, responseCache.Invalidate)
 	{
-		accounts.Post("/create", handlerV1.CreateAccount)
-		accounts.Get("/all", handlerV1.GetAllAccounts)
+		accounts.Post("/create", idempotency, handlerV1.CreateAccount)
+		accounts.Get("/all", responseCache.Cached, handlerV1.GetAllAccounts)
 		accounts.Get("/:id", handlerV1.GetAccountById)
 		accounts.Put("/:id/update", handlerV1.UpdateAccount)
-		accounts.Delete("/:id/delete", handlerV1.DeleteAccount)
+		accounts.Delete("/:id/delete", stepUp, handlerV1.DeleteAccount)
 	}
 
-	budgets := router.Group("/budgets", middleware.JWTMiddleware(casbinEnforcer))
+	budgets := router.Group("/budgets", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), rateLimit, responseCache.Invalidate)
 	{
-		budgets.Post("/create", handlerV1.CreateBudget)
-		budgets.Get("/all", handlerV1.GetAllBudgets)
+		budgets.Post("/create", idempotency, handlerV1.CreateBudget)
+		budgets.Get("/all", responseCache.Cached, handlerV1.GetAllBudgets)
 		budgets.Get("/:id", handlerV1.GetBudgetById)
 		budgets.Put("/:id/update", handlerV1.UpdateBudget)
-		budgets.Delete("/:id/delete", handlerV1.DeleteBudget)
+		budgets.Delete("/:id/delete", stepUp, handlerV1.DeleteBudget)
 	}
 
-	categories := router.Group("/categories", middleware.JWTMiddleware(casbinEnforcer))
+	categories := router.Group("/categories", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), rateLimit, responseCache.Invalidate)
 	{
-		categories.Post("/create", handlerV1.CreateCategory)
-		categories.Get("/all", handlerV1.GetAllCategories)
+		categories.Post("/create", idempotency, handlerV1.CreateCategory)
+		categories.Get("/all", responseCache.Cached, handlerV1.GetAllCategories)
 		categories.Get("/:id", handlerV1.GetCategoryById)
 		categories.Put("/:id/update", handlerV1.UpdateCategory)
-		categories.Delete("/:id/delete", handlerV1.DeleteCategory)
+		categories.Delete("/:id/delete", stepUp, handlerV1.DeleteCategory)
 	}
 
-	goals := router.Group("/goals", middleware.JWTMiddleware(casbinEnforcer))
+	goals := router.Group("/goals", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), rateLimit, responseCache.Invalidate)
 	{
-		goals.Post("/create", handlerV1.CreateGoal)
+		goals.Post("/create", idempotency, handlerV1.CreateGoal)
+		goals.Get("/all", responseCache.Cached, handlerV1.GetAllGoals)
 		goals.Get("/:id", handlerV1.GetGoalById)
-		goals.Get("/all", handlerV1.GetAllGoals)
 		goals.Put("/:id/update", handlerV1.UpdateGoal)
-		goals.Delete("/:id/delete", handlerV1.DeleteGoal)
+		goals.Delete("/:id/delete", stepUp, handlerV1.DeleteGoal)
 	}
 
-	transactions := router.Group("/transactions", middleware.JWTMiddleware(casbinEnforcer))
+	transactions := router.Group("/transactions", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), rateLimit, responseCache.Invalidate)
 	{
-		transactions.Post("/create", handlerV1.CreateTransaction)
+		transactions.Post("/create", idempotency, handlerV1.CreateTransaction)
+		transactions.Get("/all", responseCache.Cached, handlerV1.GetAllTransactions)
 		transactions.Get("/:id", handlerV1.GetTransactionById)
-		transactions.Get("/all", handlerV1.GetAllTransactions)
 		transactions.Put("/:id/update", handlerV1.UpdateTransaction)
-		transactions.Delete("/:id/delete", handlerV1.DeleteTransaction)
+		transactions.Delete("/:id/delete", stepUp, handlerV1.DeleteTransaction)
+	}
+
+	households := router.Group("/households", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), rateLimit)
+	{
+		households.Post("/create", handlerV1.CreateHousehold)
+		households.Get("/all", handlerV1.GetMyHouseholds)
+		households.Post("/accept", handlerV1.AcceptHouseholdInvitation)
+		households.Get("/:id", handlerV1.GetHouseholdById)
+		households.Post("/:id/invite", handlerV1.InviteHouseholdMember)
+		households.Delete("/:id/members/:user_id", handlerV1.RemoveHouseholdMember)
+		households.Post("/:id/share", handlerV1.ShareHouseholdResource)
+		households.Delete("/:id/resources/:type/:resource_id", handlerV1.UnshareHouseholdResource)
+	}
+
+	// the role check does not rely on the policy, so a broken policy can not hand out its own management
+	admin := router.Group("/admin", middleware.JWTMiddleware(casbinEnforcer, storage, verifier, handlerV1.UserRole, log), middleware.RequireRole("admin"), rateLimit)
+	{
+		admin.Get("/policies", handlerV1.GetPolicies)
+		admin.Post("/policies", stepUp, handlerV1.AddPolicy)
+		admin.Delete("/policies", stepUp, handlerV1.RemovePolicy)
+		admin.Post("/policies/roles", stepUp, handlerV1.AddRoleAssignment)
+		admin.Delete("/policies/roles", stepUp, handlerV1.RemoveRoleAssignment)
 	}
 
 	return router
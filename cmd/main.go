@@ -2,46 +2,215 @@ package main
 
 import (
 	"api_gateway/api"
+	"api_gateway/api/handlers/health"
+	"api_gateway/api/handlers/middleware"
+	"api_gateway/api/handlers/proxy"
 	"api_gateway/configs"
 	"api_gateway/grpc/client"
+	"api_gateway/pkg/jwt"
 	"api_gateway/pkg/logger"
 	"api_gateway/pkg/messege_brokers/kafka"
-
-	"github.com/casbin/casbin/v2"
+	"api_gateway/pkg/policy"
+	"api_gateway/pkg/ratelimit"
+	"api_gateway/pkg/tracing"
+	"api_gateway/storage/redis"
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 
 	"go.uber.org/zap"
 )
 
 func main() {
+	// os.Exit skips deferred calls, so it is deferred first to run after all of them. For the same reason
+	// startup errors are not logged with Fatal, they set exitCode and return.
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
 	config := configs.Load()
 
-	logger := logger.NewLogger(config.ServiceName, config.LoggerLevel, config.LogPath)
+	log := logger.NewLogger(config.ServiceName, config.LoggerLevel, config.LogPath)
+	defer logger.Cleanup(log)
+
+	// ctx is canceled by SIGINT or SIGTERM, it stops the background work of the gateway
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	services, err := client.NewGrpcClients(config)
+	shutdownTracing, err := tracing.NewProvider(ctx, config)
 	if err != nil {
-		logger.Fatal("Failed make client connections ", zap.Error(err))
+		log.Error("Failed to set up tracing", zap.Error(err))
+		exitCode = 1
 		return
 	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Error("Failed to flush traces", zap.Error(err))
+		}
+	}()
 
-	casbinEnforcer, err := casbin.NewEnforcer("/app/configs/model.conf", "/app/configs/policy.csv")
+	services, err := client.NewGrpcClients(ctx, config, log)
 	if err != nil {
-		logger.Error("Error while loading model and policy", zap.Error(err))
+		log.Error("Failed make client connections ", zap.Error(err))
+		exitCode = 1
 		return
 	}
+	defer func() {
+		if err := services.Close(); err != nil {
+			log.Error("Failed to close gRPC connections", zap.Error(err))
+		}
+	}()
 
-	iKafka, err := kafka.NewIKafka()
+	redisClient, err := redis.ConnectDB(config)
 	if err != nil {
-		logger.Fatal("Failed to create Kafka producer and consumer", zap.Error(err))
+		log.Error("Failed to connect to redis", zap.Error(err))
+		exitCode = 1
 		return
 	}
-	defer iKafka.Close()
+	defer redisClient.Close()
+
+	storage := redis.NewRedisStorage(redisClient)
+
+	var keySet *jwt.KeySet
+	if config.JWKSSource != "" {
+		keySet, err = jwt.NewKeySet(config.JWKSSource, log)
+		if err != nil {
+			log.Error("Failed to load JWKS", zap.Error(err))
+			exitCode = 1
+			return
+		}
+		go keySet.AutoRefresh(ctx, config.JWKSRefreshInterval)
+	}
+
+	verifier := jwt.NewVerifier(config, keySet)
 
-	router := api.NewRouter(logger, services, iKafka, casbinEnforcer)
+	authProxy := proxy.NewAuthProxy(config, log)
+	go authProxy.HealthCheck(ctx, config.AuthProxyHealthInterval)
 
-	logger.Info("Fiber router is running..")
-	err = router.Listen(config.ApiGatewayHttpHost + config.ApiGatewayHttpPort)
+	rateLimits := map[string]ratelimit.Rule{}
+	if config.RateLimitEnabled {
+		rateLimits, err = ratelimit.ParseRules(config.RateLimitRules)
+		if err != nil {
+			log.Error("Invalid RATE_LIMIT_RULES", zap.Error(err))
+			exitCode = 1
+			return
+		}
+	}
+	rateLimiter := middleware.NewRateLimiter(storage.Throttle(), rateLimits, log)
+
+	casbinEnforcer, err := policy.NewEnforcer(ctx, config, redisClient, log)
 	if err != nil {
-		logger.Fatal("Fiber router failed to run", zap.Error(err))
+		log.Error("Error while loading model and policy", zap.Error(err))
+		exitCode = 1
 		return
 	}
+
+	householdEnforcer, err := policy.NewHouseholdEnforcer(ctx, config, redisClient, log)
+	if err != nil {
+		log.Error("Error while loading household model and policy", zap.Error(err))
+		exitCode = 1
+		return
+	}
+
+	iKafka, err := kafka.NewIKafka(config.KafkaGroupId)
+	if err != nil {
+		log.Error("Failed to create Kafka producer and consumer", zap.Error(err))
+		exitCode = 1
+		return
+	}
+	defer func() {
+		if err := iKafka.Close(); err != nil {
+			log.Error("Failed to close Kafka producer and consumer", zap.Error(err))
+		}
+	}()
+
+	// the consumer must be done before Kafka is closed
+	var consumers sync.WaitGroup
+	defer consumers.Wait()
+
+	responseCache := middleware.NewResponseCache(storage, config.ResponseCacheTTL, log)
+	if config.ResponseCacheTTL > 0 {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			err := iKafka.ConsumeMessages(ctx, config.ResponseCacheTopics, responseCache.HandleEvent)
+			if err != nil {
+				log.Error("Stopped consuming response cache invalidation events", zap.Error(err))
+			}
+		}()
+	}
+
+	critical := map[string]bool{}
+	for _, name := range config.HealthCriticalDeps {
+		critical[strings.TrimSpace(name)] = true
+	}
+	checker := health.NewChecker(config.HealthCheckTimeout)
+	for name, conn := range services.Conns() {
+		checker.Add(name, critical[name], health.GrpcCheck(conn))
+	}
+	checker.Add("redis", critical["redis"], func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	checker.Add("kafka", critical["kafka"], iKafka.Ping)
+	checker.Add("auth_proxy", critical["auth_proxy"], func(context.Context) error {
+		if !authProxy.Healthy() {
+			return errors.New("auth service upstream is unhealthy")
+		}
+		return nil
+	})
+
+	router := api.NewRouter(log, services, iKafka, casbinEnforcer, householdEnforcer, storage, config, verifier, authProxy, checker, rateLimiter, responseCache)
+
+	report, err := policy.Audit(casbinEnforcer, router.GetRoutes(true), config.CasbinAuditPublicRoutes)
+	if err != nil {
+		log.Error("Failed to audit casbin policy", zap.Error(err))
+		exitCode = 1
+		return
+	}
+	for _, route := range report.Unreachable {
+		log.Warn("Route is not allowed for any role", zap.String("route", route))
+	}
+	for _, route := range report.OverPermitted {
+		log.Warn("Route is allowed only by a catch-all policy", zap.String("route", route))
+	}
+	for _, rule := range report.DeadRules {
+		log.Warn("Policy does not match any route", zap.String("rule", rule))
+	}
+	if report.Failed() && config.CasbinAuditStrict {
+		log.Error("Casbin policy does not cover the routes, fix the policy or disable CASBIN_AUDIT_STRICT")
+		exitCode = 1
+		return
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		log.Info("Fiber router is running..")
+		listenErr <- router.Listen(config.ApiGatewayHttpHost + config.ApiGatewayHttpPort)
+	}()
+
+	select {
+	case err = <-listenErr:
+		if err != nil {
+			log.Error("Fiber router failed to run", zap.Error(err))
+			exitCode = 1
+		}
+	case <-ctx.Done():
+		log.Info("Shutting down, draining in-flight requests", zap.Duration("timeout", config.ShutdownTimeout))
+		if err := router.ShutdownWithTimeout(config.ShutdownTimeout); err != nil {
+			log.Error("Failed to drain in-flight requests", zap.Error(err))
+		}
+	}
+
+	// stop the background work, the deferred calls then close the connections in reverse order
+	stop()
+	log.Info("Fiber router stopped")
 }
@@ -3,6 +3,8 @@ package configs
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
@@ -11,15 +13,44 @@ import (
 type Config struct {
 	ApiGatewayHttpHost string
 	ApiGatewayHttpPort string
+	ProxyHeader        string
+	TrustedProxies     []string
 
 	UserServiceHttpHost string
 	UserServiceHttpPort string
 	UserServiceGrpcHost string
 	UserServiceGrpcPort string
 
+	UserServiceGrpcTLS        bool
+	UserServiceGrpcCAFile     string
+	UserServiceGrpcCertFile   string
+	UserServiceGrpcKeyFile    string
+	UserServiceGrpcServerName string
+
+	AuthProxyUpstreamPrefix string
+	AuthProxyTimeout        time.Duration
+	AuthProxyHealthPath     string
+	AuthProxyHealthInterval time.Duration
+
 	BudgetingServiceGrpcHost string
 	BudgetingServiceGrpcPort string
 
+	BudgetingServiceGrpcTLS        bool
+	BudgetingServiceGrpcCAFile     string
+	BudgetingServiceGrpcCertFile   string
+	BudgetingServiceGrpcKeyFile    string
+	BudgetingServiceGrpcServerName string
+
+	GrpcTLSReloadInterval time.Duration
+
+	GrpcTimeout            time.Duration
+	GrpcMethodTimeouts     string
+	GrpcRetryMaxAttempts   int
+	GrpcRetryBackoff       time.Duration
+	GrpcIdempotentMethods  []string
+	GrpcBreakerFailures    int
+	GrpcBreakerOpenTimeout time.Duration
+
 	PostgresHost     string
 	PostgresPort     string
 	PostgresUser     string
@@ -39,6 +70,66 @@ type Config struct {
 
 	SigningKeyAccess  string
 	SigningKeyRefresh string
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+
+	JWKSSource          string
+	JWKSRefreshInterval time.Duration
+	JWTAllowHMAC        bool
+	JWTIssuer           string
+	JWTAudience         string
+	JWTLeeway           time.Duration
+
+	TOTPIssuer        string
+	TOTPEnrollmentTTL time.Duration
+	StepUpSigningKey  string
+	StepUpTokenTTL    time.Duration
+
+	PasswordResetWindow     time.Duration
+	PasswordResetEmailLimit int
+	PasswordResetIPLimit    int
+
+	CasbinModelPath         string
+	CasbinPolicyPath        string
+	CasbinPolicyVersion     string
+	CasbinAdapter           string
+	CasbinRedisKey          string
+	CasbinWatcherChannel    string
+	CasbinAuditStrict       bool
+	CasbinAuditPublicRoutes []string
+
+	HouseholdModelPath      string
+	HouseholdPolicyPath     string
+	HouseholdPolicyVersion  string
+	HouseholdRedisKey       string
+	HouseholdWatcherChannel string
+	HouseholdInvitationTTL  time.Duration
+
+	ResponseFormat string
+
+	RateLimitEnabled bool
+	RateLimitRules   string
+
+	IdempotencyTTL     time.Duration
+	IdempotencyLockTTL time.Duration
+
+	ResponseCacheTTL    time.Duration
+	ResponseCacheTopics []string
+
+	KafkaGroupId string
+
+	TracingExporter     string
+	TracingServiceName  string
+	TracingOTLPEndpoint string
+	TracingOTLPInsecure bool
+	TracingSampleRatio  float64
+
+	MetricsPath string
+
+	HealthCheckTimeout time.Duration
+	HealthCriticalDeps []string
+
+	ShutdownTimeout time.Duration
 
 	ServiceName string
 	LoggerLevel string
@@ -55,15 +146,46 @@ func Load() *Config {
 	config := Config{}
 	config.ApiGatewayHttpHost = cast.ToString(coalesce("API_GATEWAY_HTTP_HOST", "localhost"))
 	config.ApiGatewayHttpPort = cast.ToString(coalesce("API_GATEWAY_HTTP_PORT", ":8080"))
+	config.ProxyHeader = cast.ToString(coalesce("PROXY_HEADER", "X-Forwarded-For"))
+	config.TrustedProxies = strings.Split(cast.ToString(coalesce("TRUSTED_PROXIES", "")), ",")
 
 	config.UserServiceGrpcHost = cast.ToString(coalesce("USER_SERVICE_GRPC_HOST", "localhost"))
 	config.UserServiceGrpcPort = cast.ToString(coalesce("USER_SERVICE_GRPC_PORT", ":1111"))
 	config.UserServiceHttpHost = cast.ToString(coalesce("USER_SERVICE_HTTP_HOST", "localhost"))
 	config.UserServiceHttpPort = cast.ToString(coalesce("USER_SERVICE_HTTP_PORT", ":2222"))
 
+	config.UserServiceGrpcTLS = cast.ToBool(coalesce("USER_SERVICE_GRPC_TLS", false))
+	config.UserServiceGrpcCAFile = cast.ToString(coalesce("USER_SERVICE_GRPC_CA_FILE", ""))
+	config.UserServiceGrpcCertFile = cast.ToString(coalesce("USER_SERVICE_GRPC_CERT_FILE", ""))
+	config.UserServiceGrpcKeyFile = cast.ToString(coalesce("USER_SERVICE_GRPC_KEY_FILE", ""))
+	config.UserServiceGrpcServerName = cast.ToString(coalesce("USER_SERVICE_GRPC_SERVER_NAME", ""))
+
+	config.AuthProxyUpstreamPrefix = cast.ToString(coalesce("AUTH_PROXY_UPSTREAM_PREFIX", "/auth"))
+	config.AuthProxyTimeout = cast.ToDuration(coalesce("AUTH_PROXY_TIMEOUT", "10s"))
+	config.AuthProxyHealthPath = cast.ToString(coalesce("AUTH_PROXY_HEALTH_PATH", "/health"))
+	config.AuthProxyHealthInterval = cast.ToDuration(coalesce("AUTH_PROXY_HEALTH_INTERVAL", "10s"))
+
 	config.BudgetingServiceGrpcHost = cast.ToString(coalesce("BUDGETING_SERVICE_GRPC_HOST", "localhost"))
 	config.BudgetingServiceGrpcPort = cast.ToString(coalesce("BUDGETING_SERVICE_GRPC_PORT", ":3333"))
 
+	config.BudgetingServiceGrpcTLS = cast.ToBool(coalesce("BUDGETING_SERVICE_GRPC_TLS", false))
+	config.BudgetingServiceGrpcCAFile = cast.ToString(coalesce("BUDGETING_SERVICE_GRPC_CA_FILE", ""))
+	config.BudgetingServiceGrpcCertFile = cast.ToString(coalesce("BUDGETING_SERVICE_GRPC_CERT_FILE", ""))
+	config.BudgetingServiceGrpcKeyFile = cast.ToString(coalesce("BUDGETING_SERVICE_GRPC_KEY_FILE", ""))
+	config.BudgetingServiceGrpcServerName = cast.ToString(coalesce("BUDGETING_SERVICE_GRPC_SERVER_NAME", ""))
+
+	config.GrpcTLSReloadInterval = cast.ToDuration(coalesce("GRPC_TLS_RELOAD_INTERVAL", "1m"))
+
+	config.GrpcTimeout = cast.ToDuration(coalesce("GRPC_TIMEOUT", "5s"))
+	config.GrpcMethodTimeouts = cast.ToString(coalesce("GRPC_METHOD_TIMEOUTS",
+		"budgeting_service.TransactionService/GenerateSpendingReport=15s,budgeting_service.TransactionService/GenerateIncomeReport=15s,"+
+			"budgeting_service.TransactionService/GenerateBudgetPerformanceReport=15s,budgeting_service.TransactionService/GenerateGoalProgressReport=15s"))
+	config.GrpcRetryMaxAttempts = cast.ToInt(coalesce("GRPC_RETRY_MAX_ATTEMPTS", 3))
+	config.GrpcRetryBackoff = cast.ToDuration(coalesce("GRPC_RETRY_BACKOFF", "100ms"))
+	config.GrpcIdempotentMethods = strings.Split(cast.ToString(coalesce("GRPC_IDEMPOTENT_METHODS", "Get")), ",")
+	config.GrpcBreakerFailures = cast.ToInt(coalesce("GRPC_BREAKER_FAILURES", 5))
+	config.GrpcBreakerOpenTimeout = cast.ToDuration(coalesce("GRPC_BREAKER_OPEN_TIMEOUT", "30s"))
+
 	config.PostgresHost = cast.ToString(coalesce("POSTGRES_HOST", "localhost"))
 	config.PostgresPort = cast.ToString(coalesce("POSTGRES_PORT", "5432"))
 	config.PostgresUser = cast.ToString(coalesce("POSTGRES_USER", "postgres"))
@@ -83,6 +205,70 @@ func Load() *Config {
 
 	config.SigningKeyAccess = cast.ToString(coalesce("SINGNING_KEY_ACCESS", "SSECCA"))
 	config.SigningKeyRefresh = cast.ToString(coalesce("SINGNING_KEY_REFRESH", "HSERFER"))
+	config.AccessTokenTTL = cast.ToDuration(coalesce("ACCESS_TOKEN_TTL", "1h"))
+	config.RefreshTokenTTL = cast.ToDuration(coalesce("REFRESH_TOKEN_TTL", "720h"))
+
+	config.JWKSSource = cast.ToString(coalesce("JWKS_SOURCE", ""))
+	config.JWKSRefreshInterval = cast.ToDuration(coalesce("JWKS_REFRESH_INTERVAL", "5m"))
+	config.JWTAllowHMAC = cast.ToBool(coalesce("JWT_ALLOW_HMAC", true))
+	config.JWTIssuer = cast.ToString(coalesce("JWT_ISSUER", ""))
+	config.JWTAudience = cast.ToString(coalesce("JWT_AUDIENCE", ""))
+	config.JWTLeeway = cast.ToDuration(coalesce("JWT_LEEWAY", "30s"))
+
+	config.TOTPIssuer = cast.ToString(coalesce("TOTP_ISSUER", "MoneyMate"))
+	config.TOTPEnrollmentTTL = cast.ToDuration(coalesce("TOTP_ENROLLMENT_TTL", "10m"))
+	config.StepUpSigningKey = cast.ToString(coalesce("STEP_UP_SIGNING_KEY", "PU_PETS"))
+	config.StepUpTokenTTL = cast.ToDuration(coalesce("STEP_UP_TOKEN_TTL", "5m"))
+
+	config.PasswordResetWindow = cast.ToDuration(coalesce("PASSWORD_RESET_WINDOW", "15m"))
+	config.PasswordResetEmailLimit = cast.ToInt(coalesce("PASSWORD_RESET_EMAIL_LIMIT", 3))
+	config.PasswordResetIPLimit = cast.ToInt(coalesce("PASSWORD_RESET_IP_LIMIT", 10))
+
+	config.CasbinModelPath = cast.ToString(coalesce("CASBIN_MODEL_PATH", "/app/configs/model.conf"))
+	config.CasbinPolicyPath = cast.ToString(coalesce("CASBIN_POLICY_PATH", "/app/configs/policy.csv"))
+	config.CasbinPolicyVersion = cast.ToString(coalesce("CASBIN_POLICY_VERSION", "1"))
+	config.CasbinAdapter = cast.ToString(coalesce("CASBIN_ADAPTER", "redis"))
+	config.CasbinRedisKey = cast.ToString(coalesce("CASBIN_REDIS_KEY", "casbin:policy"))
+	config.CasbinWatcherChannel = cast.ToString(coalesce("CASBIN_WATCHER_CHANNEL", "casbin:policy_updated"))
+	config.CasbinAuditStrict = cast.ToBool(coalesce("CASBIN_AUDIT_STRICT", false))
+	config.CasbinAuditPublicRoutes = strings.Split(cast.ToString(coalesce("CASBIN_AUDIT_PUBLIC_ROUTES",
+		"/swagger/*,/metrics,/healthz,/readyz,/auth/*,/auth/refresh,/auth/forgot-password,/auth/reset-password")), ",")
+
+	config.HouseholdModelPath = cast.ToString(coalesce("HOUSEHOLD_MODEL_PATH", "/app/configs/household_model.conf"))
+	config.HouseholdPolicyPath = cast.ToString(coalesce("HOUSEHOLD_POLICY_PATH", "/app/configs/household_policy.csv"))
+	config.HouseholdPolicyVersion = cast.ToString(coalesce("HOUSEHOLD_POLICY_VERSION", "1"))
+	config.HouseholdRedisKey = cast.ToString(coalesce("HOUSEHOLD_REDIS_KEY", "casbin:household_policy"))
+	config.HouseholdWatcherChannel = cast.ToString(coalesce("HOUSEHOLD_WATCHER_CHANNEL", "casbin:household_policy_updated"))
+	config.HouseholdInvitationTTL = cast.ToDuration(coalesce("HOUSEHOLD_INVITATION_TTL", "72h"))
+
+	config.ResponseFormat = cast.ToString(coalesce("RESPONSE_FORMAT", "legacy"))
+
+	config.RateLimitEnabled = cast.ToBool(coalesce("RATE_LIMIT_ENABLED", true))
+	config.RateLimitRules = cast.ToString(coalesce("RATE_LIMIT_RULES",
+		"default=300/1m,/auth=30/1m,/users/password=5/15m,/users/2fa=10/5m,/transactions=120/1m"))
+
+	config.IdempotencyTTL = cast.ToDuration(coalesce("IDEMPOTENCY_TTL", "24h"))
+	config.IdempotencyLockTTL = cast.ToDuration(coalesce("IDEMPOTENCY_LOCK_TTL", "1m"))
+
+	config.ResponseCacheTTL = cast.ToDuration(coalesce("RESPONSE_CACHE_TTL", "30s"))
+	config.ResponseCacheTopics = strings.Split(cast.ToString(coalesce("RESPONSE_CACHE_TOPICS",
+		"transaction_created,budget_updated,goal_progress_updated")), ",")
+
+	config.KafkaGroupId = cast.ToString(coalesce("KAFKA_GROUP_ID", "api_gateway"))
+
+	config.TracingExporter = cast.ToString(coalesce("TRACING_EXPORTER", "none"))
+	config.TracingServiceName = cast.ToString(coalesce("TRACING_SERVICE_NAME", "api_gateway"))
+	config.TracingOTLPEndpoint = cast.ToString(coalesce("TRACING_OTLP_ENDPOINT", "localhost:4317"))
+	config.TracingOTLPInsecure = cast.ToBool(coalesce("TRACING_OTLP_INSECURE", true))
+	config.TracingSampleRatio = cast.ToFloat64(coalesce("TRACING_SAMPLE_RATIO", 1.0))
+
+	config.MetricsPath = cast.ToString(coalesce("METRICS_PATH", "/metrics"))
+
+	config.HealthCheckTimeout = cast.ToDuration(coalesce("HEALTH_CHECK_TIMEOUT", "2s"))
+	config.HealthCriticalDeps = strings.Split(cast.ToString(coalesce("HEALTH_CRITICAL_DEPS",
+		"users_service,budgeting_service,redis")), ",")
+
+	config.ShutdownTimeout = cast.ToDuration(coalesce("SHUTDOWN_TIMEOUT", "15s"))
 
 	config.ServiceName = cast.ToString(coalesce("SERVICE_NAME", "auth_service"))
 	config.LoggerLevel = cast.ToString(coalesce("LOGGER_LEVEL", "debug"))
@@ -2,11 +2,20 @@ package client
 
 import (
 	"api_gateway/configs"
+	"api_gateway/pkg/breaker"
+	"api_gateway/pkg/logger"
+	"api_gateway/pkg/tlsconfig"
+	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	pb "api_gateway/genproto/budgeting_service"
 	pbu "api_gateway/genproto/users"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -17,6 +26,8 @@ type IServiceManager interface {
 	CategoryService() pb.CategoryServiceClient
 	GoalService() pb.GoalServiceClient
 	TransactionService() pb.TransactionServiceClient
+	Conns() map[string]*grpc.ClientConn
+	Close() error
 }
 
 type grpcClients struct {
@@ -26,17 +37,55 @@ type grpcClients struct {
 	categoryService    pb.CategoryServiceClient
 	goalService        pb.GoalServiceClient
 	transactionService pb.TransactionServiceClient
+
+	conns map[string]*grpc.ClientConn
 }
 
-func NewGrpcClients(cfg *configs.Config) (IServiceManager, error) {
+// NewGrpcClients connects to the backend services. Certificates of TLS connections are reloaded
+// when they change on disk until ctx is done.
+func NewGrpcClients(ctx context.Context, cfg *configs.Config, log logger.ILogger) (IServiceManager, error) {
+	timeouts, err := ParseTimeouts(cfg.GrpcTimeout, cfg.GrpcMethodTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	retry := RetryPolicy{
+		MaxAttempts: cfg.GrpcRetryMaxAttempts,
+		Backoff:     cfg.GrpcRetryBackoff,
+		Idempotent:  cfg.GrpcIdempotentMethods,
+	}
+
+	usersCredentials, err := transportCredentials(ctx, cfg.UserServiceGrpcTLS, tlsconfig.Options{
+		CAFile:     cfg.UserServiceGrpcCAFile,
+		CertFile:   cfg.UserServiceGrpcCertFile,
+		KeyFile:    cfg.UserServiceGrpcKeyFile,
+		ServerName: cfg.UserServiceGrpcServerName,
+	}, cfg.GrpcTLSReloadInterval, log)
+	if err != nil {
+		return nil, fmt.Errorf("user service tls: %w", err)
+	}
 
-	connUsersService, err := grpc.NewClient(cfg.UserServiceGrpcHost+cfg.UserServiceGrpcPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	budgetingCredentials, err := transportCredentials(ctx, cfg.BudgetingServiceGrpcTLS, tlsconfig.Options{
+		CAFile:     cfg.BudgetingServiceGrpcCAFile,
+		CertFile:   cfg.BudgetingServiceGrpcCertFile,
+		KeyFile:    cfg.BudgetingServiceGrpcKeyFile,
+		ServerName: cfg.BudgetingServiceGrpcServerName,
+	}, cfg.GrpcTLSReloadInterval, log)
+	if err != nil {
+		return nil, fmt.Errorf("budgeting service tls: %w", err)
+	}
+
+	connUsersService, err := grpc.NewClient(cfg.UserServiceGrpcHost+cfg.UserServiceGrpcPort,
+		dialOptions(cfg, usersCredentials, timeouts, retry)...,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	connBudgetingService, err := grpc.NewClient(cfg.BudgetingServiceGrpcHost+cfg.BudgetingServiceGrpcPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	connBudgetingService, err := grpc.NewClient(cfg.BudgetingServiceGrpcHost+cfg.BudgetingServiceGrpcPort,
+		dialOptions(cfg, budgetingCredentials, timeouts, retry)...,
+	)
 	if err != nil {
+		connUsersService.Close()
 		return nil, err
 	}
 
@@ -47,6 +96,10 @@ func NewGrpcClients(cfg *configs.Config) (IServiceManager, error) {
 		categoryService:    pb.NewCategoryServiceClient(connBudgetingService),
 		goalService:        pb.NewGoalServiceClient(connBudgetingService),
 		transactionService: pb.NewTransactionServiceClient(connBudgetingService),
+		conns: map[string]*grpc.ClientConn{
+			"users_service":     connUsersService,
+			"budgeting_service": connBudgetingService,
+		},
 	}, nil
 }
 
@@ -73,3 +126,53 @@ func (g *grpcClients) GoalService() pb.GoalServiceClient {
 func (g *grpcClients) TransactionService() pb.TransactionServiceClient {
 	return g.transactionService
 }
+
+// dialOptions configures a connection to a backend service. Every connection gets its own circuit breaker.
+// The deadline covers all attempts of a call, and an open breaker fails the call before any attempt.
+func dialOptions(cfg *configs.Config, creds credentials.TransportCredentials, timeouts *Timeouts, retry RetryPolicy) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(
+			metricsInterceptor,
+			timeoutInterceptor(timeouts),
+			breakerInterceptor(breaker.New(cfg.GrpcBreakerFailures, cfg.GrpcBreakerOpenTimeout)),
+			retryInterceptor(retry),
+			requestIdInterceptor,
+			tracingInterceptor,
+		),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+}
+
+// transportCredentials returns plaintext credentials unless enabled. TLS credentials read the
+// certificates loaded last on every handshake, so new connections pick up rotated files.
+func transportCredentials(ctx context.Context, enabled bool, options tlsconfig.Options, reloadInterval time.Duration, log logger.ILogger) (credentials.TransportCredentials, error) {
+	if !enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	files, err := tlsconfig.NewFiles(options)
+	if err != nil {
+		return nil, err
+	}
+	if reloadInterval > 0 {
+		go files.AutoReload(ctx, reloadInterval, log)
+	}
+
+	return credentials.NewTLS(files.Config()), nil
+}
+
+// Conns returns the connections to the backend services by service name
+func (g *grpcClients) Conns() map[string]*grpc.ClientConn {
+	return g.conns
+}
+
+// Close closes the connections to the backend services, calls in flight are canceled
+func (g *grpcClients) Close() error {
+	var errs []error
+	for _, conn := range g.conns {
+		errs = append(errs, conn.Close())
+	}
+
+	return errors.Join(errs...)
+}
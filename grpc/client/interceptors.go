@@ -0,0 +1,43 @@
+package client
+
+import (
+	"api_gateway/pkg/metrics"
+	"api_gateway/pkg/requestid"
+	"api_gateway/pkg/tracing"
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIdInterceptor passes the request ID to the backend services in the metadata
+func requestIdInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestid.MetadataKey, id)
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// tracingInterceptor lets the OpenTelemetry stats handler find the span of the request when the call
+// was given a fasthttp request context
+func tracingInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(tracing.Context(ctx), method, req, reply, cc, opts...)
+}
+
+// metricsInterceptor observes the latency and the status code of the calls to the backend services
+func metricsInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	// method is "/package.Service/Method"
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	metrics.GrpcClientCalls.WithLabelValues(service, name, status.Code(err).String()).Inc()
+	metrics.GrpcClientCallDuration.WithLabelValues(service, name).Observe(time.Since(start).Seconds())
+
+	return err
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"api_gateway/pkg/breaker"
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Timeouts holds the deadline of the calls to the backend services. A timeout can be set for a method
+// ("package.Service/Method") or for all methods of a service ("package.Service").
+type Timeouts struct {
+	Default time.Duration
+	methods map[string]time.Duration
+}
+
+// ParseTimeouts parses a comma separated list of name=duration pairs,
+// e.g. "budgeting_service.TransactionService/GenerateSpendingReport=15s,users.UsersService=3s"
+func ParseTimeouts(defaultTimeout time.Duration, spec string) (*Timeouts, error) {
+	timeouts := &Timeouts{Default: defaultTimeout, methods: map[string]time.Duration{}}
+
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid timeout %q, expected name=duration", pair)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid duration in timeout %q", pair)
+		}
+		timeouts.methods[strings.Trim(strings.TrimSpace(name), "/")] = timeout
+	}
+
+	return timeouts, nil
+}
+
+// For returns the timeout of method, given as "/package.Service/Method"
+func (t *Timeouts) For(method string) time.Duration {
+	method = strings.TrimPrefix(method, "/")
+	if timeout, ok := t.methods[method]; ok {
+		return timeout
+	}
+
+	service, _, _ := strings.Cut(method, "/")
+	if timeout, ok := t.methods[service]; ok {
+		return timeout
+	}
+
+	return t.Default
+}
+
+// RetryPolicy retries calls of idempotent methods that failed because the backend was unavailable.
+// The wait between attempts starts at Backoff and doubles, with jitter, within the deadline of the call.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	// Idempotent are prefixes of the names of the methods that are safe to repeat
+	Idempotent []string
+}
+
+func (p RetryPolicy) retries(method string) bool {
+	if p.MaxAttempts < 2 {
+		return false
+	}
+
+	name := method[strings.LastIndex(method, "/")+1:]
+	for _, prefix := range p.Idempotent {
+		if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// timeoutInterceptor gives the call the deadline of its method. The call is detached from the
+// cancellation of ctx: the handlers pass the fasthttp RequestCtx, which is done as soon as the server
+// starts shutting down, and in-flight calls must be able to finish within the shutdown timeout. The
+// values of ctx, such as the request ID and the span, are kept.
+func timeoutInterceptor(timeouts *Timeouts) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = context.WithoutCancel(ctx)
+		if timeout := timeouts.For(method); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// breakerInterceptor fails calls fast with Unavailable while the breaker of the backend is open
+func breakerInterceptor(b *breaker.Breaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		wait, ok := b.Allow()
+		if !ok {
+			// RetryInfo becomes the Retry-After header of the response
+			st := status.New(codes.Unavailable, "circuit breaker of "+cc.Target()+" is open")
+			if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+				st = detailed
+			}
+			return st.Err()
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.Done(backendFailed(err))
+
+		return err
+	}
+}
+
+// retryInterceptor repeats idempotent calls according to policy
+func retryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !policy.retries(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		backoff := policy.Backoff
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || status.Code(err) != codes.Unavailable || attempt >= policy.MaxAttempts {
+				return err
+			}
+
+			wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// backendFailed tells the errors that show the backend can not serve calls. Any other error is an
+// answer of the backend, so it works.
+func backendFailed(err error) bool {
+	code := status.Code(err)
+	return code == codes.Unavailable || code == codes.DeadlineExceeded
+}
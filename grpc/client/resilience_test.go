@@ -0,0 +1,89 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{
+			name: "method and service timeouts",
+			spec: "budgeting_service.TransactionService/GenerateSpendingReport=15s,users.UsersService=3s",
+			want: map[string]time.Duration{
+				"/budgeting_service.TransactionService/GenerateSpendingReport": 15 * time.Second,
+				"/budgeting_service.TransactionService/GetById":                5 * time.Second,
+				"/users.UsersService/GetUserProfile":                           3 * time.Second,
+				"/budgeting_service.AccountService/GetById":                    5 * time.Second,
+			},
+		},
+		{
+			name: "a method timeout wins over its service",
+			spec: "users.UsersService=3s,users.UsersService/ResetPassword=10s",
+			want: map[string]time.Duration{
+				"/users.UsersService/ResetPassword":  10 * time.Second,
+				"/users.UsersService/GetUserProfile": 3 * time.Second,
+			},
+		},
+		{
+			name: "spaces, slashes and empty items are ignored",
+			spec: " /users.UsersService/ = 3s ,, ",
+			want: map[string]time.Duration{"/users.UsersService/GetUserProfile": 3 * time.Second},
+		},
+		{
+			name: "empty spec uses the default",
+			spec: "",
+			want: map[string]time.Duration{"/users.UsersService/GetUserProfile": 5 * time.Second},
+		},
+		{name: "missing duration", spec: "users.UsersService", wantErr: true},
+		{name: "invalid duration", spec: "users.UsersService=3", wantErr: true},
+		{name: "zero duration", spec: "users.UsersService=0s", wantErr: true},
+		{name: "negative duration", spec: "users.UsersService=-1s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeouts, err := ParseTimeouts(5*time.Second, tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for method, want := range tt.want {
+				if got := timeouts.For(method); got != want {
+					t.Errorf("For(%q) = %v, want %v", method, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyRetries(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Idempotent: []string{"Get", " List ", ""}}
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		method string
+		want   bool
+	}{
+		{"idempotent prefix", policy, "/budgeting_service.AccountService/GetById", true},
+		{"prefix with spaces", policy, "/budgeting_service.AccountService/ListAll", true},
+		{"not idempotent", policy, "/budgeting_service.AccountService/Create", false},
+		{"the prefix must start the method name", policy, "/budgeting_service.AccountService/ForgetAll", false},
+		{"the service name is not matched", policy, "/Get.AccountService/Create", false},
+		{"a single attempt never retries", RetryPolicy{MaxAttempts: 1, Idempotent: []string{"Get"}}, "/users.UsersService/GetUserProfile", false},
+		{"no idempotent methods", RetryPolicy{MaxAttempts: 3}, "/users.UsersService/GetUserProfile", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.retries(tt.method); got != tt.want {
+				t.Errorf("retries(%q) = %v, want %v", tt.method, got, tt.want)
+			}
+		})
+	}
+}
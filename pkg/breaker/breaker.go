@@ -0,0 +1,105 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	// Closed lets all calls through and counts consecutive failures
+	Closed State = iota
+	// Open fails all calls fast until the open timeout passes
+	Open
+	// HalfOpen lets a single probe call through, its outcome closes or reopens the breaker
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker stops calls to a backend after threshold consecutive failures. After openTimeout a probe
+// call is let through, the breaker closes again when it succeeds.
+type Breaker struct {
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	state       State
+	failures    int
+	openedAt    time.Time
+}
+
+// New builds a breaker, a threshold below 1 disables it
+func New(threshold int, openTimeout time.Duration) *Breaker {
+	return &Breaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+	}
+}
+
+// Allow reports whether a call may be made. When it may not, the duration tells how long the breaker
+// stays open. Every allowed call must be followed by Done.
+func (b *Breaker) Allow() (time.Duration, bool) {
+	if b.threshold < 1 {
+		return 0, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		remaining := b.openTimeout - time.Since(b.openedAt)
+		if remaining > 0 {
+			return remaining, false
+		}
+		b.state = HalfOpen
+		return 0, true
+	case HalfOpen:
+		// the probe is still in flight
+		return b.openTimeout, false
+	default:
+		return 0, true
+	}
+}
+
+// Done records the outcome of an allowed call
+func (b *Breaker) Done(failed bool) {
+	if b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state = Closed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		b.state = Open
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
@@ -0,0 +1,127 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+// call is one step of a scenario: a call is asked for and, when it is allowed, finishes with failed
+type call struct {
+	failed    bool
+	wantAllow bool
+	wantState State
+}
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name        string
+		threshold   int
+		openTimeout time.Duration
+		calls       []call
+	}{
+		{
+			name:        "stays closed below the threshold",
+			threshold:   3,
+			openTimeout: time.Hour,
+			calls: []call{
+				{failed: true, wantAllow: true, wantState: Closed},
+				{failed: true, wantAllow: true, wantState: Closed},
+			},
+		},
+		{
+			name:        "a success resets the failures",
+			threshold:   2,
+			openTimeout: time.Hour,
+			calls: []call{
+				{failed: true, wantAllow: true, wantState: Closed},
+				{failed: false, wantAllow: true, wantState: Closed},
+				{failed: true, wantAllow: true, wantState: Closed},
+			},
+		},
+		{
+			name:        "opens at the threshold and fails calls fast",
+			threshold:   2,
+			openTimeout: time.Hour,
+			calls: []call{
+				{failed: true, wantAllow: true, wantState: Closed},
+				{failed: true, wantAllow: true, wantState: Open},
+				{wantAllow: false, wantState: Open},
+			},
+		},
+		{
+			name:        "a successful probe closes it",
+			threshold:   1,
+			openTimeout: 0,
+			calls: []call{
+				{failed: true, wantAllow: true, wantState: Open},
+				{failed: false, wantAllow: true, wantState: Closed},
+				{failed: false, wantAllow: true, wantState: Closed},
+			},
+		},
+		{
+			name:        "a failed probe opens it again",
+			threshold:   3,
+			openTimeout: 0,
+			calls: []call{
+				{failed: true, wantAllow: true, wantState: Closed},
+				{failed: true, wantAllow: true, wantState: Closed},
+				{failed: true, wantAllow: true, wantState: Open},
+				{failed: true, wantAllow: true, wantState: Open},
+			},
+		},
+		{
+			name:        "a threshold below 1 disables it",
+			threshold:   0,
+			openTimeout: time.Hour,
+			calls: []call{
+				{failed: true, wantAllow: true, wantState: Closed},
+				{failed: true, wantAllow: true, wantState: Closed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.threshold, tt.openTimeout)
+			for i, c := range tt.calls {
+				_, allowed := b.Allow()
+				if allowed != c.wantAllow {
+					t.Fatalf("call %d: Allow() = %v, want %v", i, allowed, c.wantAllow)
+				}
+				if allowed {
+					b.Done(c.failed)
+				}
+				if state := b.State(); state != c.wantState {
+					t.Fatalf("call %d: State() = %v, want %v", i, state, c.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	b := New(1, time.Millisecond)
+	b.Allow()
+	b.Done(true)
+
+	wait, allowed := b.Allow()
+	if allowed || wait <= 0 {
+		t.Fatalf("Allow() right after opening = %v, %v, want a wait and false", wait, allowed)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if _, allowed = b.Allow(); !allowed {
+		t.Fatal("Allow() after the open timeout rejected the probe")
+	}
+	if state := b.State(); state != HalfOpen {
+		t.Fatalf("State() during the probe = %v, want %v", state, HalfOpen)
+	}
+	if _, allowed = b.Allow(); allowed {
+		t.Fatal("Allow() let a second call through while the probe is in flight")
+	}
+
+	b.Done(false)
+	if state := b.State(); state != Closed {
+		t.Fatalf("State() after a successful probe = %v, want %v", state, Closed)
+	}
+}
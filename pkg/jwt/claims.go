@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	ErrTokenUsedBefore  = errors.New("token used before issued")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+	ErrInvalidTokenType = errors.New("invalid token type")
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeAPIKey  = "api_key"
+	TokenTypeStepUp  = "step_up"
+)
+
+// Claims is the typed payload of the tokens accepted by the gateway
+type Claims struct {
+	Id        string   `json:"jti,omitempty"`
+	Type      string   `json:"type,omitempty"`
+	FamilyId  string   `json:"fid,omitempty"`
+	UserId    string   `json:"user_id"`
+	Username  string   `json:"username,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	FirstName string   `json:"first_name,omitempty"`
+	LastName  string   `json:"last_name,omitempty"`
+	Role      string   `json:"role"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	Scopes    []string `json:"scopes,omitempty"`
+}
+
+// ValidationOptions are the expectations every token is checked against
+type ValidationOptions struct {
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// Audience accepts both the single string and the array form of the aud claim
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("aud must be a string or an array of strings")
+	}
+	*a = list
+
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid satisfies jwt.Claims. Time and issuer checks need the gateway configuration,
+// so they are done by Validate which the Verifier calls after the signature check.
+func (c *Claims) Valid() error {
+	return nil
+}
+
+// Validate checks the required claims, issuer, audience and the exp/nbf/iat window
+func (c *Claims) Validate(opts ValidationOptions, now time.Time) error {
+	switch {
+	case c.UserId == "":
+		return fmt.Errorf("token is missing required claim: user_id")
+	case c.Role == "":
+		return fmt.Errorf("token is missing required claim: role")
+	case c.ExpiresAt == 0:
+		return fmt.Errorf("token is missing required claim: exp")
+	}
+
+	if opts.Issuer != "" && c.Issuer != opts.Issuer {
+		return ErrInvalidIssuer
+	}
+	if opts.Audience != "" && !c.Audience.Contains(opts.Audience) {
+		return ErrInvalidAudience
+	}
+
+	if now.Add(-opts.Leeway).Unix() >= c.ExpiresAt {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(opts.Leeway).Unix() < c.NotBefore {
+		return ErrTokenNotValidYet
+	}
+	if c.IssuedAt != 0 && now.Add(opts.Leeway).Unix() < c.IssuedAt {
+		return ErrTokenUsedBefore
+	}
+
+	return nil
+}
+
+// HasScope reports whether an API key principal was granted scope
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Claims) ExpiresIn(now time.Time) time.Duration {
+	return time.Unix(c.ExpiresAt, 0).Sub(now)
+}
@@ -0,0 +1,67 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClaimsValidate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	valid := func(change func(c *Claims)) *Claims {
+		c := &Claims{UserId: "user", Role: "user", Issuer: "issuer", Audience: Audience{"gateway"}, ExpiresAt: 2000}
+		change(c)
+		return c
+	}
+	opts := func(leeway time.Duration) ValidationOptions {
+		return ValidationOptions{Issuer: "issuer", Audience: "gateway", Leeway: leeway}
+	}
+
+	tests := []struct {
+		name    string
+		claims  *Claims
+		opts    ValidationOptions
+		wantErr error
+	}{
+		{"valid", valid(func(c *Claims) {}), opts(0), nil},
+		{"expires now", valid(func(c *Claims) { c.ExpiresAt = 1000 }), opts(0), ErrTokenExpired},
+		{"expires in a second", valid(func(c *Claims) { c.ExpiresAt = 1001 }), opts(0), nil},
+		{"expired within the leeway", valid(func(c *Claims) { c.ExpiresAt = 971 }), opts(30 * time.Second), nil},
+		{"expired at the end of the leeway", valid(func(c *Claims) { c.ExpiresAt = 970 }), opts(30 * time.Second), ErrTokenExpired},
+		{"not valid yet", valid(func(c *Claims) { c.NotBefore = 1001 }), opts(0), ErrTokenNotValidYet},
+		{"not before within the leeway", valid(func(c *Claims) { c.NotBefore = 1030 }), opts(30 * time.Second), nil},
+		{"not before beyond the leeway", valid(func(c *Claims) { c.NotBefore = 1031 }), opts(30 * time.Second), ErrTokenNotValidYet},
+		{"issued in the future within the leeway", valid(func(c *Claims) { c.IssuedAt = 1030 }), opts(30 * time.Second), nil},
+		{"issued in the future beyond the leeway", valid(func(c *Claims) { c.IssuedAt = 1031 }), opts(30 * time.Second), ErrTokenUsedBefore},
+		{"wrong issuer", valid(func(c *Claims) { c.Issuer = "other" }), opts(0), ErrInvalidIssuer},
+		{"wrong audience", valid(func(c *Claims) { c.Audience = Audience{"other"} }), opts(0), ErrInvalidAudience},
+		{"issuer and audience are not required", valid(func(c *Claims) { c.Issuer, c.Audience = "", nil }), ValidationOptions{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.claims.Validate(tt.opts, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClaimsValidateRequiredClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *Claims
+	}{
+		{"missing user_id", &Claims{Role: "user", ExpiresAt: 2000}},
+		{"missing role", &Claims{UserId: "user", ExpiresAt: 2000}},
+		{"missing exp", &Claims{UserId: "user", Role: "user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.claims.Validate(ValidationOptions{}, time.Unix(1000, 0)); err == nil {
+				t.Error("Validate() accepted a token without a required claim")
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package jwt
+
+import (
+	"api_gateway/pkg/logger"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minForcedRefreshInterval limits how often an unknown kid can trigger a reload of the key set
+const minForcedRefreshInterval = 30 * time.Second
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// KeySet holds public keys loaded from a JWKS document, selected by kid
+type KeySet struct {
+	source      string
+	client      *http.Client
+	mu          sync.RWMutex
+	keys        map[string]interface{}
+	lastAttempt time.Time
+	refreshes   singleflight.Group
+	log         logger.ILogger
+}
+
+// NewKeySet loads a JWKS from source, which is either an http(s) URL or a local file path
+func NewKeySet(source string, log logger.ILogger) (*KeySet, error) {
+	k := &KeySet{
+		source: source,
+		client: &http.Client{Timeout: 10 * time.Second},
+		keys:   map[string]interface{}{},
+		log:    log,
+	}
+
+	if err := k.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Refresh reloads the key set from its source. Keys are replaced only if the new document is valid.
+// Keys of a type or curve the gateway does not support are skipped, the others are still used.
+func (k *KeySet) Refresh() error {
+	// a failed attempt counts too, so that an unreachable source is not hit by every unknown kid
+	k.mu.Lock()
+	k.lastAttempt = time.Now()
+	k.mu.Unlock()
+
+	data, err := k.read()
+	if err != nil {
+		return fmt.Errorf("failed to read jwks from %s: %w", k.source, err)
+	}
+
+	set := jsonWebKeySet{}
+	if err = json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			k.log.Warn("skipping jwk", logger.String("kid", jwk.Kid), logger.String("kty", jwk.Kty), logger.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("jwks from %s has no signing keys", k.source)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+
+	return nil
+}
+
+// AutoRefresh reloads the key set every interval until ctx is done
+func (k *KeySet) AutoRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Refresh(); err != nil {
+				k.log.Error("failed to refresh jwks", logger.Error(err))
+			}
+		}
+	}
+}
+
+// Key returns the public key with the given kid. An unknown kid forces a reload
+// so that keys rotated in by the auth service are picked up without waiting for the next tick.
+// Concurrent lookups share a single reload.
+func (k *KeySet) Key(kid string) (interface{}, error) {
+	k.mu.RLock()
+	key, ok := k.keys[kid]
+	k.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	_, err, _ := k.refreshes.Do("refresh", func() (interface{}, error) {
+		k.mu.RLock()
+		lastAttempt := k.lastAttempt
+		k.mu.RUnlock()
+		if time.Since(lastAttempt) < minForcedRefreshInterval {
+			return nil, nil
+		}
+		return nil, k.Refresh()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	key, ok = k.keys[kid]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %q", kid)
+	}
+
+	return key, nil
+}
+
+func (k *KeySet) read() ([]byte, error) {
+	if !strings.HasPrefix(k.source, "http://") && !strings.HasPrefix(k.source, "https://") {
+		return os.ReadFile(k.source)
+	}
+
+	resp, err := k.client.Get(k.source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (j *jsonWebKey) publicKey() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBase64URL(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 3 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(exponent.Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", j.Crv)
+		}
+
+		x, err := decodeBase64URL(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBase64URL(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("point is not on curve %s", j.Crv)
+		}
+
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", j.Kty)
+	}
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,72 @@
+package jwt
+
+import (
+	"api_gateway/pkg/logger"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeySetRefresh(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey := jsonWebKey{
+		Kty: "EC",
+		Kid: "ec",
+		Use: "sig",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(private.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(private.Y.Bytes()),
+	}
+	okpKey := jsonWebKey{Kty: "OKP", Kid: "okp", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+	badCurve := jsonWebKey{Kty: "EC", Kid: "secp256k1", Crv: "secp256k1", X: ecKey.X, Y: ecKey.Y}
+	encryption := jsonWebKey{Kty: "RSA", Kid: "enc", Use: "enc"}
+
+	tests := []struct {
+		name     string
+		keys     []jsonWebKey
+		wantKids []string
+		wantErr  bool
+	}{
+		{"a supported key is loaded", []jsonWebKey{ecKey}, []string{"ec"}, false},
+		{"unsupported keys are skipped", []jsonWebKey{okpKey, ecKey, badCurve}, []string{"ec"}, false},
+		{"encryption keys are skipped", []jsonWebKey{encryption, ecKey}, []string{"ec"}, false},
+		{"a set without a usable key is refused", []jsonWebKey{okpKey, badCurve}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(jsonWebKeySet{Keys: tt.keys})
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			if err = os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			keySet, err := NewKeySet(path, logger.NewLogger("test", logger.LevelError, os.DevNull))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewKeySet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(keySet.keys) != len(tt.wantKids) {
+				t.Errorf("loaded %d keys, want %v", len(keySet.keys), tt.wantKids)
+			}
+			for _, kid := range tt.wantKids {
+				if _, ok := keySet.keys[kid]; !ok {
+					t.Errorf("key %q was not loaded", kid)
+				}
+			}
+		})
+	}
+}
@@ -2,27 +2,234 @@ package jwt
 
 import (
 	"api_gateway/configs"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"fmt"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 )
 
-func ExtractClaims(tokenStr string) (jwt.MapClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
+// UserClaims is the user information embedded in issued tokens
+type UserClaims struct {
+	UserId    string
+	Username  string
+	Email     string
+	FirstName string
+	LastName  string
+	Role      string
+}
+
+// Verifier validates access tokens signed either with the shared HMAC key
+// or with an RSA/ECDSA key from the JWKS, selected by the kid header
+type Verifier struct {
+	hmacKey    []byte
+	refreshKey []byte
+	stepUpKey  []byte
+	keys       *KeySet
+	opts       ValidationOptions
+	parser     *jwt.Parser
+}
+
+// NewVerifier creates a verifier. keys may be nil when no JWKS is configured.
+func NewVerifier(cfg *configs.Config, keys *KeySet) *Verifier {
+	v := &Verifier{
+		refreshKey: []byte(cfg.SigningKeyRefresh),
+		stepUpKey:  []byte(cfg.StepUpSigningKey),
+		keys:       keys,
+		opts: ValidationOptions{
+			Issuer:   cfg.JWTIssuer,
+			Audience: cfg.JWTAudience,
+			Leeway:   cfg.JWTLeeway,
+		},
+		parser: &jwt.Parser{SkipClaimsValidation: true},
+	}
+	if cfg.JWTAllowHMAC {
+		v.hmacKey = []byte(cfg.SigningKeyAccess)
+	}
+
+	return v
+}
+
+// ExtractClaims verifies an access token and returns its validated claims
+func (v *Verifier) ExtractClaims(tokenStr string) (*Claims, error) {
+	claims, err := v.parse(tokenStr, v.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != "" && claims.Type != TokenTypeAccess {
+		return nil, ErrInvalidTokenType
+	}
+
+	return claims, nil
+}
+
+// ExtractRefreshClaims verifies a refresh token signed with the refresh key and returns its validated claims
+func (v *Verifier) ExtractRefreshClaims(tokenStr string) (*Claims, error) {
+	claims, err := v.parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(configs.Load().SigningKeyAccess), nil
+		return v.refreshKey, nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != TokenTypeRefresh {
+		return nil, ErrInvalidTokenType
+	}
+
+	return claims, nil
+}
+
+// ExtractStepUpClaims verifies a step-up token issued by GenerateStepUpToken
+func (v *Verifier) ExtractStepUpClaims(tokenStr string) (*Claims, error) {
+	claims, err := v.parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return v.stepUpKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != TokenTypeStepUp {
+		return nil, ErrInvalidTokenType
+	}
+
+	return claims, nil
+}
+
+// GenerateStepUpToken issues a short-lived token proving that the user has just passed a second factor check.
+// It is signed with a key only the gateway knows, the auth service can not issue one.
+func GenerateStepUpToken(cfg *configs.Config, userId, role string) (string, error) {
+	now := time.Now()
+
+	claims := &Claims{
+		Id:        uuid.NewString(),
+		Type:      TokenTypeStepUp,
+		UserId:    userId,
+		Role:      role,
+		Issuer:    cfg.JWTIssuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(cfg.StepUpTokenTTL).Unix(),
+	}
+	if cfg.JWTAudience != "" {
+		claims.Audience = Audience{cfg.JWTAudience}
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.StepUpSigningKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign step-up token: %w", err)
+	}
+
+	return token, nil
+}
+
+// GenerateTokens issues a new access/refresh pair. The refresh token carries
+// familyId so that every rotation of one login session can be revoked at once, and the email so that
+// a password reset, which knows the user only by email, revokes it too.
+// The access token is signed with HS256, verifiers accept it only with JWT_ALLOW_HMAC.
+func GenerateTokens(cfg *configs.Config, user *UserClaims, familyId string) (string, string, error) {
+	now := time.Now()
+
+	var audience Audience
+	if cfg.JWTAudience != "" {
+		audience = Audience{cfg.JWTAudience}
+	}
+
+	accessClaims := &Claims{
+		Id:        uuid.NewString(),
+		Type:      TokenTypeAccess,
+		UserId:    user.UserId,
+		Username:  user.Username,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Role:      user.Role,
+		Issuer:    cfg.JWTIssuer,
+		Audience:  audience,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(cfg.AccessTokenTTL).Unix(),
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString([]byte(cfg.SigningKeyAccess))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	jti := uuid.NewString()
+	if familyId == "" {
+		familyId = jti
+	}
+	refreshClaims := &Claims{
+		Id:        jti,
+		Type:      TokenTypeRefresh,
+		FamilyId:  familyId,
+		UserId:    user.UserId,
+		Email:     user.Email,
+		Role:      user.Role,
+		Issuer:    cfg.JWTIssuer,
+		Audience:  audience,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(cfg.RefreshTokenTTL).Unix(),
+	}
+	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(cfg.SigningKeyRefresh))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign refresh token: %w", err)
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func (v *Verifier) parse(tokenStr string, keyFunc jwt.Keyfunc) (*Claims, error) {
+	claims := &Claims{}
+	token, err := v.parser.ParseWithClaims(tokenStr, claims, keyFunc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 	if !token.Valid {
 		return nil, fmt.Errorf("invalid token")
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, fmt.Errorf("invalid token claims")
+	if err = claims.Validate(v.opts, time.Now()); err != nil {
+		return nil, err
 	}
+
 	return claims, nil
 }
+
+func (v *Verifier) keyFunc(t *jwt.Token) (interface{}, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(v.hmacKey) == 0 {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return v.hmacKey, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if v.keys == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("token has no kid header")
+		}
+		key, err := v.keys.Key(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); ok {
+				return key, nil
+			}
+		case *ecdsa.PublicKey:
+			if _, ok := t.Method.(*jwt.SigningMethodECDSA); ok {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("key %q does not match signing method %v", kid, t.Header["alg"])
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+}
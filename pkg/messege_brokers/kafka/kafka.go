@@ -1,25 +1,40 @@
 package kafka
 
 import (
+	"api_gateway/pkg/metrics"
+	"api_gateway/pkg/requestid"
+	"api_gateway/pkg/tracing"
 	"context"
+	"errors"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type IKafka interface {
-	Close()
-	ProduceMessage(string, string) error
-	ConsumeMessages(string) error
+	Close() error
+	ProduceMessage(context.Context, string, string) error
+	ConsumeMessages(context.Context, []string, MessageHandler) error
+	Ping(context.Context) error
 }
 
+// MessageHandler is called for every consumed message, ctx carries the request ID of the record
+type MessageHandler func(ctx context.Context, topic string, value []byte)
+
 type kafka struct {
+	client   sarama.Client
 	producer sarama.SyncProducer
 	consumer sarama.ConsumerGroup
 }
 
 type consumerGroupHandler struct {
+	handle MessageHandler
 }
 
 func (h *consumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
@@ -32,80 +47,204 @@ func (h *consumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
 
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	for message := range claim.Messages() {
-		switch message.Partition {
-		case 0:
-
+		ctx := session.Context()
+		for _, header := range message.Headers {
+			if string(header.Key) == requestid.Header {
+				ctx = requestid.NewContext(ctx, string(header.Value))
+			}
 		}
+
+		carrier := consumerHeaderCarrier(message.Headers)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+		ctx, span := tracing.Tracer().Start(ctx, message.Topic+" process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				semconv.MessagingSystemKafka,
+				semconv.MessagingOperationTypeDeliver,
+				semconv.MessagingDestinationName(message.Topic),
+				semconv.MessagingKafkaMessageOffset(int(message.Offset)),
+				semconv.MessagingDestinationPartitionID(strconv.Itoa(int(message.Partition))),
+			),
+		)
+
+		h.handle(ctx, message.Topic, message.Value)
+		session.MarkMessage(message, "")
+		span.End()
 	}
 
 	return nil
 }
 
-func NewIKafka() (IKafka, error) {
-	producer, err := newKafkaProducer()
+func NewIKafka(groupId string) (IKafka, error) {
+	client, producer, err := newKafkaProducer()
 	if err != nil {
 		return nil, err
 	}
 
-	consumer, err := newKafkaConsumer()
+	consumer, err := newKafkaConsumer(groupId)
 	if err != nil {
 		return nil, err
 	}
 
 	return &kafka{
+		client:   client,
 		producer: producer,
 		consumer: consumer,
 	}, nil
 }
 
-func (k *kafka) ProduceMessage(topic, value string) error {
+// ProduceMessage sends value to topic, the request ID and the trace context of ctx go along in record headers
+func (k *kafka) ProduceMessage(ctx context.Context, topic, value string) error {
 	message := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.StringEncoder(value),
 	}
-	_, _, err := k.producer.SendMessage(message)
+	if id := requestid.FromContext(ctx); id != "" {
+		message.Headers = []sarama.RecordHeader{{Key: []byte(requestid.Header), Value: []byte(id)}}
+	}
+
+	ctx, span := tracing.Tracer().Start(tracing.Context(ctx), topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(topic),
+		),
+	)
+	defer span.End()
+
+	otel.GetTextMapPropagator().Inject(ctx, (*producerHeaderCarrier)(message))
+
+	partition, offset, err := k.producer.SendMessage(message)
+	metrics.KafkaProducedMessages.WithLabelValues(topic, metrics.Result(err)).Inc()
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
+	span.SetAttributes(
+		semconv.MessagingKafkaMessageOffset(int(offset)),
+		semconv.MessagingDestinationPartitionID(strconv.Itoa(int(partition))),
+	)
 
 	return nil
 }
 
-func (k *kafka) ConsumeMessages(topic string) error {
-	handler := consumerGroupHandler{}
+// ConsumeMessages passes the messages of topics to handle until ctx is done
+func (k *kafka) ConsumeMessages(ctx context.Context, topics []string, handle MessageHandler) error {
+	handler := consumerGroupHandler{handle: handle}
 	for {
-		err := k.consumer.Consume(context.Background(), []string{topic}, &handler)
+		err := k.consumer.Consume(ctx, topics, &handler)
+		if ctx.Err() != nil {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
 	}
 }
 
-func (k *kafka) Close() {
-	k.producer.Close()
-	k.consumer.Close()
+// producerHeaderCarrier lets the propagators write the trace context into the headers of a message
+type producerHeaderCarrier sarama.ProducerMessage
+
+func (c *producerHeaderCarrier) Get(key string) string {
+	for _, h := range c.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c *producerHeaderCarrier) Set(key, value string) {
+	for i, h := range c.Headers {
+		if string(h.Key) == key {
+			c.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.Headers = append(c.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
 }
 
-func newKafkaProducer() (sarama.SyncProducer, error) {
+func (c *producerHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.Headers))
+	for _, h := range c.Headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+// consumerHeaderCarrier lets the propagators read the trace context from the headers of a consumed message
+type consumerHeaderCarrier []*sarama.RecordHeader
+
+func (c consumerHeaderCarrier) Get(key string) string {
+	for _, h := range c {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerHeaderCarrier) Set(string, string) {}
+
+func (c consumerHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for _, h := range c {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+// Ping refreshes the cluster metadata of the producer, which fails while no broker can be reached
+func (k *kafka) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- k.client.RefreshMetadata()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close waits for the messages in flight to be acknowledged, then closes the producer and the consumer
+func (k *kafka) Close() error {
+	return errors.Join(
+		k.producer.Close(),
+		k.client.Close(),
+		k.consumer.Close(),
+	)
+}
+
+func newKafkaProducer() (sarama.Client, sarama.SyncProducer, error) {
 	config := sarama.NewConfig()
 	config.Consumer.Return.Errors = true
 	config.Producer.Return.Successes = true
 	sarama.Logger = log.New(os.Stdout, "[sarama] ", log.LstdFlags)
 
-	producer, err := sarama.NewSyncProducer([]string{"kafka1:29092"}, config)
+	client, err := sarama.NewClient([]string{"kafka1:29092"}, config)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
 	}
-	return producer, nil
+	return client, producer, nil
 }
 
-func newKafkaConsumer() (sarama.ConsumerGroup, error) {
+func newKafkaConsumer(groupId string) (sarama.ConsumerGroup, error) {
 	config := sarama.NewConfig()
 	config.Consumer.Return.Errors = true
 	config.Producer.Return.Successes = true
 	sarama.Logger = log.New(os.Stdout, "[sarama] ", log.LstdFlags)
 
-	consumer, err := sarama.NewConsumerGroup([]string{"kafka1:29092"}, "", config)
+	consumer, err := sarama.NewConsumerGroup([]string{"kafka1:29092"}, groupId, config)
 	if err != nil {
 		return nil, err
 	}
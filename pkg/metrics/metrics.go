@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "api_gateway"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	// HTTPRequests counts the handled requests by route template and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes the handling time of requests by route template
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time spent handling HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// GrpcClientCalls counts the calls to the backend services by method and gRPC status code
+	GrpcClientCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "calls_total",
+		Help:      "Number of completed gRPC calls to the backend services.",
+	}, []string{"service", "method", "code"})
+
+	// GrpcClientCallDuration observes the latency of the calls to the backend services by method
+	GrpcClientCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "call_duration_seconds",
+		Help:      "Latency of gRPC calls to the backend services.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "method"})
+
+	// KafkaProducedMessages counts the messages sent to Kafka by topic and result
+	KafkaProducedMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "produced_messages_total",
+		Help:      "Number of messages produced to Kafka.",
+	}, []string{"topic", "result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		GrpcClientCalls,
+		GrpcClientCallDuration,
+		KafkaProducedMessages,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Result labels the outcome of an operation by its error
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
@@ -0,0 +1,175 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Report lists the problems Audit found in the policy
+type Report struct {
+	// Unreachable are protected routes that no role is allowed to call
+	Unreachable []string
+	// OverPermitted are routes a role can call only because of a catch-all rule
+	OverPermitted []string
+	// DeadRules are rules that do not match any route
+	DeadRules []string
+}
+
+// Failed reports whether the policy does not cover the routes exactly. Dead rules are harmless and do not count.
+func (r *Report) Failed() bool {
+	return len(r.Unreachable) > 0 || len(r.OverPermitted) > 0
+}
+
+// Audit evaluates every protected route against the policy for every known role.
+// Routes whose path is listed in public are not behind JWTMiddleware and are skipped.
+func Audit(enforcer *casbin.SyncedEnforcer, routes []fiber.Route, public []string) (*Report, error) {
+	roles, err := knownRoles(enforcer)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := enforcer.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	narrow, err := withoutCatchAll(enforcer)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[string]bool, len(public))
+	for _, path := range public {
+		skip[path] = true
+	}
+
+	report := &Report{}
+	matched := make([]bool, len(policies))
+	seen := map[string]bool{}
+
+	for _, route := range routes {
+		// fiber registers a HEAD route for every GET route
+		if route.Method == fiber.MethodHead || skip[route.Path] {
+			continue
+		}
+
+		name := route.Method + " " + route.Path
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		for i, rule := range policies {
+			if len(rule) >= 3 && util.KeyMatch2(route.Path, rule[1]) && util.KeyMatch2(route.Method, rule[2]) {
+				matched[i] = true
+			}
+		}
+
+		reachable := false
+		for _, role := range roles {
+			allowed, err := enforcer.Enforce(role, route.Path, route.Method)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				continue
+			}
+			reachable = true
+
+			allowed, err = narrow.Enforce(role, route.Path, route.Method)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				report.OverPermitted = append(report.OverPermitted, fmt.Sprintf("%s (role %s)", name, role))
+			}
+		}
+
+		if !reachable {
+			report.Unreachable = append(report.Unreachable, name)
+		}
+	}
+
+	for i, rule := range policies {
+		if !matched[i] && !isCatchAll(rule) {
+			report.DeadRules = append(report.DeadRules, ruleLine("p", rule))
+		}
+	}
+
+	return report, nil
+}
+
+// knownRoles returns every subject and role mentioned in the policy
+func knownRoles(enforcer *casbin.SyncedEnforcer) ([]string, error) {
+	subjects, err := enforcer.GetAllSubjects()
+	if err != nil {
+		return nil, err
+	}
+	grouping, err := enforcer.GetGroupingPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[string]bool{}
+	for _, subject := range subjects {
+		set[subject] = true
+	}
+	for _, rule := range grouping {
+		for _, role := range rule {
+			set[role] = true
+		}
+	}
+
+	roles := make([]string, 0, len(set))
+	for role := range set {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	return roles, nil
+}
+
+// withoutCatchAll returns a detached copy of enforcer that does not have the catch-all rules
+func withoutCatchAll(enforcer *casbin.SyncedEnforcer) (*casbin.Enforcer, error) {
+	policies, err := enforcer.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	grouping, err := enforcer.GetGroupingPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	m := enforcer.GetModel().Copy()
+	m.ClearPolicy()
+
+	narrow, err := casbin.NewEnforcer(m)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range policies {
+		if isCatchAll(rule) {
+			continue
+		}
+		if _, err = narrow.AddPolicy(rule); err != nil {
+			return nil, err
+		}
+	}
+	if len(grouping) > 0 {
+		if _, err = narrow.AddGroupingPolicies(grouping); err != nil {
+			return nil, err
+		}
+	}
+
+	return narrow, nil
+}
+
+// isCatchAll reports whether rule grants access to every path
+func isCatchAll(rule []string) bool {
+	return len(rule) >= 2 && (rule[1] == "*" || rule[1] == "/*")
+}
@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+const auditModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && keyMatch2(r.obj, p.obj) && keyMatch2(r.act, p.act)
+`
+
+func TestAudit(t *testing.T) {
+	routes := []fiber.Route{
+		{Method: fiber.MethodGet, Path: "/accounts/:id"},
+		{Method: fiber.MethodHead, Path: "/accounts/:id"},
+		{Method: fiber.MethodPost, Path: "/accounts/create"},
+		{Method: fiber.MethodGet, Path: "/admin/stats"},
+		{Method: fiber.MethodGet, Path: "/healthz"},
+	}
+	public := []string{"/healthz"}
+
+	tests := []struct {
+		name     string
+		policies [][]string
+		grouping [][]string
+		want     Report
+		failed   bool
+	}{
+		{
+			name: "every route is covered exactly",
+			policies: [][]string{
+				{"user", "/accounts/:id", "GET"},
+				{"user", "/accounts/create", "POST"},
+				{"admin", "/admin/stats", "GET"},
+			},
+			grouping: [][]string{{"admin", "user"}},
+		},
+		{
+			name: "a route no role may call is unreachable",
+			policies: [][]string{
+				{"user", "/accounts/:id", "GET"},
+				{"admin", "/admin/stats", "GET"},
+			},
+			want:   Report{Unreachable: []string{"POST /accounts/create"}},
+			failed: true,
+		},
+		{
+			name: "a route allowed only by a catch-all is over-permitted",
+			policies: [][]string{
+				{"user", "/accounts/:id", "GET"},
+				{"user", "/accounts/create", "POST"},
+				{"admin", "/*", "*"},
+			},
+			grouping: [][]string{{"admin", "user"}},
+			want:     Report{OverPermitted: []string{"GET /admin/stats (role admin)"}},
+			failed:   true,
+		},
+		{
+			name: "a rule that matches no route is dead but does not fail the audit",
+			policies: [][]string{
+				{"user", "/accounts/:id", "GET"},
+				{"user", "/accounts/create", "POST"},
+				{"user", "/accounts/:id", "DELETE"},
+				{"admin", "/admin/stats", "GET"},
+			},
+			want: Report{DeadRules: []string{"p, user, /accounts/:id, DELETE"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := model.NewModelFromString(auditModel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			enforcer, err := casbin.NewSyncedEnforcer(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = enforcer.AddPolicies(tt.policies); err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.grouping) > 0 {
+				if _, err = enforcer.AddGroupingPolicies(tt.grouping); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			report, err := Audit(enforcer, routes, public)
+			if err != nil {
+				t.Fatalf("Audit() error = %v", err)
+			}
+			if !reflect.DeepEqual(*report, tt.want) {
+				t.Errorf("Audit() = %+v, want %+v", *report, tt.want)
+			}
+			if report.Failed() != tt.failed {
+				t.Errorf("Failed() = %v, want %v", report.Failed(), tt.failed)
+			}
+		})
+	}
+}
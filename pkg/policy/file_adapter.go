@@ -0,0 +1,153 @@
+package policy
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+)
+
+// FileAdapter keeps the policy in a csv file. Unlike the adapter shipped with casbin it supports auto-save,
+// so single rules can be added and removed while comments and grouping of the file are kept.
+type FileAdapter struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileAdapter(path string) *FileAdapter {
+	return &FileAdapter{
+		path: path,
+	}
+}
+
+func (a *FileAdapter) LoadPolicy(m model.Model) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	lines, err := a.readLines()
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if err = persist.LoadPolicyLine(strings.TrimSpace(line), m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *FileAdapter) SavePolicy(m model.Model) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.writeLines(modelLines(m))
+}
+
+func (a *FileAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	lines, err := a.readLines()
+	if err != nil {
+		return err
+	}
+
+	return a.writeLines(append(lines, ruleLine(ptype, rule)))
+}
+
+func (a *FileAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	lines, err := a.readLines()
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		lines = append(lines, ruleLine(ptype, rule))
+	}
+
+	return a.writeLines(lines)
+}
+
+func (a *FileAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	return a.removeLines(func(tokens []string) bool {
+		return matchRule(tokens, ptype, rule)
+	})
+}
+
+func (a *FileAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	return a.removeLines(func(tokens []string) bool {
+		for _, rule := range rules {
+			if matchRule(tokens, ptype, rule) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (a *FileAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	return a.removeLines(func(tokens []string) bool {
+		return matchFilter(tokens, ptype, fieldIndex, fieldValues...)
+	})
+}
+
+func (a *FileAdapter) removeLines(match func(tokens []string) bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	lines, err := a.readLines()
+	if err != nil {
+		return err
+	}
+
+	kept := lines[:0]
+	for _, line := range lines {
+		tokens, err := parseLine(line)
+		if err != nil {
+			return err
+		}
+		if !match(tokens) {
+			kept = append(kept, line)
+		}
+	}
+
+	return a.writeLines(kept)
+}
+
+func (a *FileAdapter) readLines() ([]string, error) {
+	if a.path == "" {
+		return nil, errors.New("casbin policy file path is empty")
+	}
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
+
+// writeLines replaces the file atomically, so a gateway loading the policy never sees it half written
+func (a *FileAdapter) writeLines(lines []string) error {
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, a.path)
+}
@@ -0,0 +1,144 @@
+package policy
+
+import (
+	"api_gateway/configs"
+	"api_gateway/pkg/logger"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/redis/go-redis/v9"
+)
+
+// both adapters support batches, casbin requires it for removing all roles of a user in a domain
+var (
+	_ persist.BatchAdapter = (*FileAdapter)(nil)
+	_ persist.BatchAdapter = (*RedisAdapter)(nil)
+)
+
+const (
+	AdapterFile  = "file"
+	AdapterRedis = "redis"
+)
+
+// NewEnforcer builds the enforcer from the configured model and policy adapter. Every change made through it is
+// persisted by the adapter. With the Redis adapter it is also announced to the other gateway replicas, which reload
+// their policy. The file adapter is meant for a single gateway: every replica has its own file, so changes stay local.
+func NewEnforcer(ctx context.Context, cfg *configs.Config, client *redis.Client, log logger.ILogger) (*casbin.SyncedEnforcer, error) {
+	switch cfg.CasbinAdapter {
+	case AdapterFile:
+		// reloading on an announcement would only read the unchanged local file again
+		log.Warn("casbin policy is kept in a local file, policy changes apply to this replica only", logger.String("path", cfg.CasbinPolicyPath))
+		return casbin.NewSyncedEnforcer(cfg.CasbinModelPath, NewFileAdapter(cfg.CasbinPolicyPath))
+	case AdapterRedis:
+		adapter := NewRedisAdapter(client, cfg.CasbinRedisKey, cfg.CasbinPolicyPath, cfg.CasbinPolicyVersion)
+		return newWatchedEnforcer(ctx, cfg.CasbinModelPath, adapter, client, cfg.CasbinWatcherChannel, log)
+	default:
+		return nil, fmt.Errorf("unknown casbin adapter %q, expected %q or %q", cfg.CasbinAdapter, AdapterFile, AdapterRedis)
+	}
+}
+
+// NewHouseholdEnforcer builds the domain aware enforcer that holds the household roles. Memberships are user data,
+// so they always live in Redis; the role permissions are seeded from the household policy file.
+func NewHouseholdEnforcer(ctx context.Context, cfg *configs.Config, client *redis.Client, log logger.ILogger) (*casbin.SyncedEnforcer, error) {
+	adapter := NewRedisAdapter(client, cfg.HouseholdRedisKey, cfg.HouseholdPolicyPath, cfg.HouseholdPolicyVersion)
+
+	return newWatchedEnforcer(ctx, cfg.HouseholdModelPath, adapter, client, cfg.HouseholdWatcherChannel, log)
+}
+
+func newWatchedEnforcer(ctx context.Context, modelPath string, adapter persist.Adapter, client *redis.Client, channel string, log logger.ILogger) (*casbin.SyncedEnforcer, error) {
+	enforcer, err := casbin.NewSyncedEnforcer(modelPath, adapter)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := NewWatcher(ctx, client, channel, log)
+	if err != nil {
+		return nil, err
+	}
+	if err = enforcer.SetWatcher(watcher); err != nil {
+		return nil, err
+	}
+
+	// the default callback reloads the embedded enforcer without taking the lock of the synced one
+	err = watcher.SetUpdateCallback(func(string) {
+		if err := enforcer.LoadPolicy(); err != nil {
+			log.Error("failed to reload casbin policy", logger.String("model", modelPath), logger.Error(err))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enforcer, nil
+}
+
+// ruleLine formats a rule the same way policy.csv does
+func ruleLine(ptype string, rule []string) string {
+	return strings.Join(append([]string{ptype}, rule...), ", ")
+}
+
+func ruleLines(ptype string, rules [][]string) []string {
+	lines := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		lines = append(lines, ruleLine(ptype, rule))
+	}
+
+	return lines
+}
+
+// parseLine splits a policy line into its ptype and values, it returns nil for blank lines and comments
+func parseLine(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	r := csv.NewReader(strings.NewReader(line))
+	r.TrimLeadingSpace = true
+
+	return r.Read()
+}
+
+// matchRule reports whether tokens is the rule ptype with the given values
+func matchRule(tokens []string, ptype string, rule []string) bool {
+	return matchFilter(tokens, ptype, 0, rule...) && len(tokens) == len(rule)+1
+}
+
+// matchFilter reports whether tokens is a ptype rule whose values starting at fieldIndex equal fieldValues.
+// Empty filter values match anything.
+func matchFilter(tokens []string, ptype string, fieldIndex int, fieldValues ...string) bool {
+	if len(tokens) == 0 || tokens[0] != ptype {
+		return false
+	}
+
+	values := tokens[1:]
+	for i, v := range fieldValues {
+		if v == "" {
+			continue
+		}
+		if fieldIndex+i >= len(values) || values[fieldIndex+i] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// modelLines returns all rules of m as policy lines
+func modelLines(m model.Model) []string {
+	var lines []string
+	for _, sec := range []string{"p", "g"} {
+		for ptype, assertion := range m[sec] {
+			for _, rule := range assertion.Policy {
+				lines = append(lines, ruleLine(ptype, rule))
+			}
+		}
+	}
+
+	return lines
+}
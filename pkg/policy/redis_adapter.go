@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/redis/go-redis/v9"
+)
+
+// seedScript applies a version of the policy file to the store in one step. KEYS are the policy set, the
+// marker holding the applied version and the set of the lines that version added; ARGV is the version
+// followed by the lines of the file. Lines of the previous version that the file no longer has are removed,
+// the rules added through the API are kept.
+var seedScript = redis.NewScript(`
+if redis.call("GET", KEYS[2]) == ARGV[1] then
+	return 0
+end
+for _, line in ipairs(redis.call("SMEMBERS", KEYS[3])) do
+	redis.call("SREM", KEYS[1], line)
+end
+redis.call("DEL", KEYS[3])
+for i = 2, #ARGV do
+	redis.call("SADD", KEYS[1], ARGV[i])
+	redis.call("SADD", KEYS[3], ARGV[i])
+end
+redis.call("SET", KEYS[2], ARGV[1])
+return 1
+`)
+
+// RedisAdapter keeps the policy lines in a Redis set shared by all gateway replicas.
+// The set is seeded from the policy file when it is used for the first time, and again whenever
+// seedVersion changes, so that edits of the file reach a store that was already seeded.
+type RedisAdapter struct {
+	client      *redis.Client
+	key         string
+	seedPath    string
+	seedVersion string
+}
+
+func NewRedisAdapter(client *redis.Client, key, seedPath, seedVersion string) *RedisAdapter {
+	return &RedisAdapter{
+		client:      client,
+		key:         key,
+		seedPath:    seedPath,
+		seedVersion: seedVersion,
+	}
+}
+
+func (a *RedisAdapter) LoadPolicy(m model.Model) error {
+	ctx := context.Background()
+
+	if err := a.seed(ctx); err != nil {
+		return err
+	}
+
+	lines, err := a.client.SMembers(ctx, a.key).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if err = persist.LoadPolicyLine(line, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *RedisAdapter) SavePolicy(m model.Model) error {
+	lines := modelLines(m)
+
+	_, err := a.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.Background(), a.key)
+		if len(lines) > 0 {
+			pipe.SAdd(context.Background(), a.key, toArgs(lines)...)
+		}
+		return nil
+	})
+
+	return err
+}
+
+func (a *RedisAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return a.client.SAdd(context.Background(), a.key, ruleLine(ptype, rule)).Err()
+}
+
+func (a *RedisAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	return a.client.SAdd(context.Background(), a.key, toArgs(ruleLines(ptype, rules))...).Err()
+}
+
+func (a *RedisAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	return a.client.SRem(context.Background(), a.key, ruleLine(ptype, rule)).Err()
+}
+
+func (a *RedisAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	return a.client.SRem(context.Background(), a.key, toArgs(ruleLines(ptype, rules))...).Err()
+}
+
+func (a *RedisAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	ctx := context.Background()
+
+	lines, err := a.client.SMembers(ctx, a.key).Result()
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+	for _, line := range lines {
+		tokens, err := parseLine(line)
+		if err != nil {
+			return err
+		}
+		if matchFilter(tokens, ptype, fieldIndex, fieldValues...) {
+			removed = append(removed, line)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	return a.client.SRem(ctx, a.key, toArgs(removed)...).Err()
+}
+
+// seed copies the policy file into Redis once per seed version. The marker key makes sure only the first
+// replica does it and that a policy emptied through the API is not seeded again.
+func (a *RedisAdapter) seed(ctx context.Context) error {
+	if a.seedPath == "" {
+		return nil
+	}
+
+	applied, err := a.client.Get(ctx, a.key+":seeded").Result()
+	if err == nil && applied == a.seedVersion {
+		return nil
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	data, err := os.ReadFile(a.seedPath)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{a.seedVersion}
+	for _, line := range strings.Split(string(data), "\n") {
+		tokens, err := parseLine(line)
+		if err != nil {
+			return err
+		}
+		if tokens != nil {
+			args = append(args, ruleLine(tokens[0], tokens[1:]))
+		}
+	}
+
+	return seedScript.Run(ctx, a.client, []string{a.key, a.key + ":seeded", a.key + ":seed_lines"}, args...).Err()
+}
+
+func toArgs(lines []string) []interface{} {
+	args := make([]interface{}, 0, len(lines))
+	for _, line := range lines {
+		args = append(args, line)
+	}
+
+	return args
+}
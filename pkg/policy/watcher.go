@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"api_gateway/pkg/logger"
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Watcher tells the other gateway replicas over Redis pub/sub that the policy was changed
+type Watcher struct {
+	client   *redis.Client
+	channel  string
+	id       string
+	pubsub   *redis.PubSub
+	log      logger.ILogger
+	mu       sync.RWMutex
+	callback func(string)
+}
+
+// NewWatcher subscribes to channel and keeps listening until ctx is done or the watcher is closed
+func NewWatcher(ctx context.Context, client *redis.Client, channel string, log logger.ILogger) (*Watcher, error) {
+	pubsub := client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	w := &Watcher{
+		client:  client,
+		channel: channel,
+		id:      uuid.NewString(),
+		pubsub:  pubsub,
+		log:     log,
+	}
+
+	go w.listen(ctx)
+
+	return w, nil
+}
+
+func (w *Watcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.callback = callback
+
+	return nil
+}
+
+// Update is called by the enforcer after it changed the policy
+func (w *Watcher) Update() error {
+	return w.client.Publish(context.Background(), w.channel, w.id).Err()
+}
+
+func (w *Watcher) Close() {
+	w.pubsub.Close()
+}
+
+func (w *Watcher) listen(ctx context.Context) {
+	messages := w.pubsub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.Close()
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			// this replica already has the change in memory
+			if msg.Payload == w.id {
+				continue
+			}
+
+			w.mu.RLock()
+			callback := w.callback
+			w.mu.RUnlock()
+
+			if callback != nil {
+				w.log.Info("casbin policy changed by another replica", logger.String("replica", msg.Payload))
+				callback(msg.Payload)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often counters of idle keys are dropped
+const sweepInterval = time.Minute
+
+type counter struct {
+	slot     int64
+	window   time.Duration
+	previous int64
+	current  int64
+}
+
+// Memory is a sliding window limiter local to a single replica. It is used while Redis is unreachable.
+type Memory struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		counters:  make(map[string]*counter),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow counts a request for key and reports whether it fits in limit. It returns the number of requests
+// in the sliding window including this one, or the current number if the request was refused.
+func (m *Memory) Allow(ctx context.Context, key string, limit int64, window time.Duration) (int64, bool, error) {
+	now := time.Now()
+	slot, elapsed := Slot(now, window)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > sweepInterval {
+		m.sweep(now)
+	}
+
+	c, ok := m.counters[key]
+	if !ok || c.window != window {
+		c = &counter{slot: slot, window: window}
+		m.counters[key] = c
+	}
+	switch {
+	case c.slot == slot-1:
+		c.previous, c.current = c.current, 0
+	case c.slot < slot-1:
+		c.previous, c.current = 0, 0
+	}
+	c.slot = slot
+
+	count := Estimate(c.previous, c.current, elapsed, window)
+	if count >= limit {
+		return count, false, nil
+	}
+	c.current++
+
+	return count + 1, true, nil
+}
+
+// sweep drops the counters that can no longer influence any window
+func (m *Memory) sweep(now time.Time) {
+	for key, c := range m.counters {
+		slot, _ := Slot(now, c.window)
+		if c.slot < slot-1 {
+			delete(m.counters, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRule is the name of the rule used for paths no other rule matches
+const DefaultRule = "default"
+
+// Rule allows Limit requests in every sliding Window
+type Rule struct {
+	Limit  int64
+	Window time.Duration
+}
+
+// ParseRules parses a comma separated list of prefix=limit/window rules,
+// e.g. "default=300/1m,/transactions=120/1m,/users/password=5/15m"
+func ParseRules(spec string) (map[string]Rule, error) {
+	rules := make(map[string]Rule)
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		prefix, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q must look like prefix=limit/window", item)
+		}
+		limit, window, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q must look like prefix=limit/window", item)
+		}
+
+		rule := Rule{}
+		var err error
+		if rule.Limit, err = strconv.ParseInt(strings.TrimSpace(limit), 10, 64); err != nil || rule.Limit <= 0 {
+			return nil, fmt.Errorf("rate limit rule %q has an invalid limit", item)
+		}
+		if rule.Window, err = time.ParseDuration(strings.TrimSpace(window)); err != nil || rule.Window < time.Second {
+			return nil, fmt.Errorf("rate limit rule %q has an invalid window, it must be at least 1s", item)
+		}
+
+		rules[strings.TrimSpace(prefix)] = rule
+	}
+
+	return rules, nil
+}
+
+// Slot returns the index of the fixed window now falls into and how far into that window it is
+func Slot(now time.Time, window time.Duration) (int64, time.Duration) {
+	ms := now.UnixMilli()
+	size := window.Milliseconds()
+
+	return ms / size, time.Duration(ms%size) * time.Millisecond
+}
+
+// Estimate approximates the number of requests in the sliding window that ends now. The previous fixed window
+// is weighted by the part of it the sliding window still overlaps.
+func Estimate(previous, current int64, elapsed, window time.Duration) int64 {
+	return previous*int64(window-elapsed)/int64(window) + current
+}
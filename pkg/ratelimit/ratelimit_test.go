@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]Rule
+		wantErr bool
+	}{
+		{
+			name: "several rules",
+			spec: "default=300/1m,/transactions=120/1m,/users/password=5/15m",
+			want: map[string]Rule{
+				DefaultRule:       {Limit: 300, Window: time.Minute},
+				"/transactions":   {Limit: 120, Window: time.Minute},
+				"/users/password": {Limit: 5, Window: 15 * time.Minute},
+			},
+		},
+		{
+			name: "spaces and empty items are ignored",
+			spec: " default = 10 / 1s ,, ",
+			want: map[string]Rule{DefaultRule: {Limit: 10, Window: time.Second}},
+		},
+		{name: "empty spec", spec: "", want: map[string]Rule{}},
+		{name: "missing limit", spec: "default", wantErr: true},
+		{name: "missing window", spec: "default=10", wantErr: true},
+		{name: "limit is not a number", spec: "default=ten/1m", wantErr: true},
+		{name: "limit is zero", spec: "default=0/1m", wantErr: true},
+		{name: "window is not a duration", spec: "default=10/minute", wantErr: true},
+		{name: "window is below a second", spec: "default=10/500ms", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRules(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlot(t *testing.T) {
+	tests := []struct {
+		name        string
+		now         time.Time
+		window      time.Duration
+		wantSlot    int64
+		wantElapsed time.Duration
+	}{
+		{"start of a window", time.UnixMilli(120_000), time.Minute, 2, 0},
+		{"inside a window", time.UnixMilli(150_500), time.Minute, 2, 30500 * time.Millisecond},
+		{"last millisecond of a window", time.UnixMilli(179_999), time.Minute, 2, 59999 * time.Millisecond},
+		{"short window", time.UnixMilli(2_250), time.Second, 2, 250 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot, elapsed := Slot(tt.now, tt.window)
+			if slot != tt.wantSlot || elapsed != tt.wantElapsed {
+				t.Errorf("Slot() = %d, %v, want %d, %v", slot, elapsed, tt.wantSlot, tt.wantElapsed)
+			}
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current int64
+		elapsed           time.Duration
+		want              int64
+	}{
+		{"start of the window counts the whole previous window", 100, 0, 0, 100},
+		{"half way weighs the previous window by half", 100, 10, 30 * time.Second, 60},
+		{"the weighted part is rounded down", 3, 1, 30 * time.Second, 2},
+		{"near the end only the current window is left", 100, 7, 59999 * time.Millisecond, 7},
+		{"no previous requests", 0, 42, 10 * time.Second, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Estimate(tt.previous, tt.current, tt.elapsed, time.Minute); got != tt.want {
+				t.Errorf("Estimate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package requestid
+
+import (
+	"context"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Header carries the request ID over HTTP and in Kafka record headers
+	Header = "X-Request-ID"
+	// MetadataKey carries the request ID in gRPC metadata
+	MetadataKey = "x-request-id"
+
+	maxLength = 128
+)
+
+type contextKey struct{}
+
+// Key is the context key of the request ID. A fasthttp request context resolves values from its user
+// values, so storing the ID as a fiber local makes it visible through ctx.Context() as well.
+var Key = contextKey{}
+
+// New generates a request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether an ID received from a client can be passed on. It must be short and printable,
+// so that it can not break log lines or headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewContext returns a copy of ctx that carries id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, Key, id)
+}
+
+// FromContext returns the request ID carried by ctx, it is empty when there is none
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(Key).(string)
+	return id
+}
@@ -0,0 +1,173 @@
+package tlsconfig
+
+import (
+	"api_gateway/pkg/logger"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Options describe the TLS connection to one backend. With CAFile set, the backend certificate must be
+// issued by that CA, the system roots are not trusted. CertFile and KeyFile enable mTLS.
+type Options struct {
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// Files holds the client side of a TLS connection loaded from disk. Handshakes always use the last
+// loaded files, so rotated certificates are used by new connections without a restart.
+type Files struct {
+	options  Options
+	mu       sync.RWMutex
+	roots    *x509.CertPool
+	cert     *tls.Certificate
+	modTimes map[string]time.Time
+}
+
+// NewFiles loads the files of options, it fails if any of them is missing or invalid
+func NewFiles(options Options) (*Files, error) {
+	if (options.CertFile == "") != (options.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+
+	f := &Files{options: options}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Reload reads the files again. The loaded ones are replaced only if all new files are valid.
+func (f *Files) Reload() error {
+	modTimes := map[string]time.Time{}
+	for _, name := range []string{f.options.CAFile, f.options.CertFile, f.options.KeyFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		modTimes[name] = info.ModTime()
+	}
+
+	var roots *x509.CertPool
+	if f.options.CAFile != "" {
+		pem, err := os.ReadFile(f.options.CAFile)
+		if err != nil {
+			return err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", f.options.CAFile)
+		}
+	}
+
+	var cert *tls.Certificate
+	if f.options.CertFile != "" {
+		pair, err := tls.LoadX509KeyPair(f.options.CertFile, f.options.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cert = &pair
+	}
+
+	f.mu.Lock()
+	f.roots = roots
+	f.cert = cert
+	f.modTimes = modTimes
+	f.mu.Unlock()
+
+	return nil
+}
+
+// AutoReload reloads the files every interval when one of them changed, until ctx is done
+func (f *Files) AutoReload(ctx context.Context, interval time.Duration, log logger.ILogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !f.changed() {
+				continue
+			}
+			if err := f.Reload(); err != nil {
+				log.Error("failed to reload tls certificates, keeping the loaded ones", logger.String("server_name", f.options.ServerName), logger.Error(err))
+				continue
+			}
+			log.Info("reloaded tls certificates", logger.String("server_name", f.options.ServerName))
+		}
+	}
+}
+
+// Config returns the client configuration, it reads the loaded files on every handshake
+func (f *Files) Config() *tls.Config {
+	config := &tls.Config{
+		ServerName: f.options.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if f.options.CertFile != "" {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			f.mu.RLock()
+			defer f.mu.RUnlock()
+			return f.cert, nil
+		}
+	}
+
+	if f.options.CAFile != "" {
+		// RootCAs can not change after the config is built, the chain is verified against the
+		// current pool in VerifyConnection instead
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = f.verify
+	}
+
+	return config
+}
+
+func (f *Files) verify(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server sent no certificate")
+	}
+
+	f.mu.RLock()
+	roots := f.roots
+	f.mu.RUnlock()
+
+	options := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       state.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		options.Intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(options)
+	return err
+}
+
+func (f *Files) changed() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for name, modTime := range f.modTimes {
+		info, err := os.Stat(name)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+
+	return false
+}
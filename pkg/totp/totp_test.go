@@ -0,0 +1,69 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateRFC6238Vectors(t *testing.T) {
+	// the RFC lists 8 digit codes, a 6 digit code is their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		step, ok := Validate(rfcSecret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("Validate(%d, %s) rejected the RFC code", tt.unix, tt.code)
+			continue
+		}
+		if want := tt.unix / 30; step != want {
+			t.Errorf("Validate(%d, %s) step = %d, want %d", tt.unix, tt.code, step, want)
+		}
+		if code, err := Code(rfcSecret, time.Unix(tt.unix, 0)); err != nil || code != tt.code {
+			t.Errorf("Code(%d) = %s, %v, want %s", tt.unix, code, err, tt.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		t      time.Time
+		ok     bool
+	}{
+		{"current step", rfcSecret, "050471", at, true},
+		{"previous step is within the skew", rfcSecret, "050471", at.Add(Period), true},
+		{"next step is within the skew", rfcSecret, "050471", at.Add(-Period), true},
+		{"two steps late", rfcSecret, "050471", at.Add(2 * Period), false},
+		{"two steps early", rfcSecret, "050471", at.Add(-2 * Period), false},
+		{"wrong code", rfcSecret, "050472", at, false},
+		{"too short", rfcSecret, "05047", at, false},
+		{"too long", rfcSecret, "0504710", at, false},
+		{"lower case secret with spaces", " gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", "050471", at, true},
+		{"invalid secret", "not base32!", "050471", at, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(tt.secret, tt.code, tt.t); ok != tt.ok {
+				t.Errorf("Validate() ok = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"api_gateway/configs"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+
+	// InstrumentationName names the tracer of the gateway's own spans
+	InstrumentationName = "api_gateway"
+)
+
+type contextKey struct{}
+
+// Key is the context key of the current span. OpenTelemetry keeps the span under an unexported key,
+// which a fasthttp request context can not hold, so the Fiber middleware stores it under Key instead
+// and Context moves it to where OpenTelemetry looks for it.
+var Key = contextKey{}
+
+// NewProvider installs the global tracer provider and the W3C trace context and baggage propagators.
+// The returned function flushes the spans that were not exported yet and stops the provider.
+func NewProvider(ctx context.Context, cfg *configs.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch cfg.TracingExporter {
+	case ExporterOTLP:
+		options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.TracingOTLPEndpoint)}
+		if cfg.TracingOTLPInsecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case ExporterNone, "":
+		// the default provider of otel is a no-op, spans are not even recorded
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected %s, %s or %s", cfg.TracingExporter, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.TracingServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the gateway's own spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Context returns ctx with the span stored under Key, so that OpenTelemetry instrumentation given a
+// fasthttp request context continues the trace of the request
+func Context(ctx context.Context) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	if span, ok := ctx.Value(Key).(trace.Span); ok {
+		return trace.ContextWithSpan(ctx, span)
+	}
+
+	return ctx
+}
+
+// RequestHeaderCarrier lets the propagators read and write the headers of a fasthttp request
+type RequestHeaderCarrier struct {
+	Header *fasthttp.RequestHeader
+}
+
+func (c RequestHeaderCarrier) Get(key string) string {
+	return string(c.Header.Peek(key))
+}
+
+func (c RequestHeaderCarrier) Set(key, value string) {
+	c.Header.Set(key, value)
+}
+
+func (c RequestHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, c.Header.Len())
+	c.Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
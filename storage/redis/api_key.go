@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	apiKeyHashPrefix = "api_key:hash:"
+	apiKeyIdPrefix   = "api_key:id:"
+	apiKeyUserPrefix = "api_key:user:"
+)
+
+type apiKeyRepo struct {
+	client *redis.Client
+}
+
+func NewAPIKeyRepo(client *redis.Client) storage.IAPIKeyStorage {
+	return &apiKeyRepo{
+		client: client,
+	}
+}
+
+// Create stores the key under its hash only; the plain key is never persisted
+func (a *apiKeyRepo) Create(ctx context.Context, hash string, key *models.APIKey, ttl time.Duration) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	pipe := a.client.TxPipeline()
+	pipe.Set(ctx, apiKeyHashPrefix+hash, data, ttl)
+	pipe.Set(ctx, apiKeyIdPrefix+key.Id, hash, ttl)
+	pipe.SAdd(ctx, apiKeyUserPrefix+key.UserId, key.Id)
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+func (a *apiKeyRepo) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	data, err := a.client.Get(ctx, apiKeyHashPrefix+hash).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := models.APIKey{}
+	if err = json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (a *apiKeyRepo) GetAllByUser(ctx context.Context, userId string) ([]*models.APIKey, error) {
+	ids, err := a.client.SMembers(ctx, apiKeyUserPrefix+userId).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []*models.APIKey{}
+	for _, id := range ids {
+		hash, err := a.client.Get(ctx, apiKeyIdPrefix+id).Result()
+		if errors.Is(err, redis.Nil) {
+			// the key has expired, forget it
+			a.client.SRem(ctx, apiKeyUserPrefix+userId, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := a.GetByHash(ctx, hash)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (a *apiKeyRepo) Delete(ctx context.Context, userId, id string) error {
+	isMember, err := a.client.SIsMember(ctx, apiKeyUserPrefix+userId, id).Result()
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return storage.ErrNotFound
+	}
+
+	hash, err := a.client.Get(ctx, apiKeyIdPrefix+id).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	pipe := a.client.TxPipeline()
+	if hash != "" {
+		pipe.Del(ctx, apiKeyHashPrefix+hash)
+	}
+	pipe.Del(ctx, apiKeyIdPrefix+id)
+	pipe.SRem(ctx, apiKeyUserPrefix+userId, id)
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,168 @@
+package redis
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	householdIdPrefix         = "household:id:"
+	householdInvitationPrefix = "household:invitation:"
+	householdSharedPrefix     = "household:shared:"
+	householdResourcesPrefix  = "household:resources:"
+)
+
+type householdRepo struct {
+	client *redis.Client
+}
+
+func NewHouseholdRepo(client *redis.Client) storage.IHouseholdStorage {
+	return &householdRepo{
+		client: client,
+	}
+}
+
+func (h *householdRepo) Create(ctx context.Context, household *models.Household) error {
+	data, err := json.Marshal(household)
+	if err != nil {
+		return err
+	}
+
+	return h.client.Set(ctx, householdIdPrefix+household.Id, data, 0).Err()
+}
+
+func (h *householdRepo) Get(ctx context.Context, id string) (*models.Household, error) {
+	data, err := h.client.Get(ctx, householdIdPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	household := models.Household{}
+	if err = json.Unmarshal(data, &household); err != nil {
+		return nil, err
+	}
+
+	return &household, nil
+}
+
+func (h *householdRepo) CreateInvitation(ctx context.Context, invitation *models.HouseholdInvitation, ttl time.Duration) error {
+	data, err := json.Marshal(invitation)
+	if err != nil {
+		return err
+	}
+
+	return h.client.Set(ctx, householdInvitationPrefix+invitation.Token, data, ttl).Err()
+}
+
+func (h *householdRepo) GetInvitation(ctx context.Context, token string) (*models.HouseholdInvitation, error) {
+	data, err := h.client.Get(ctx, householdInvitationPrefix+token).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := models.HouseholdInvitation{}
+	if err = json.Unmarshal(data, &invitation); err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// DeleteInvitation fails with storage.ErrNotFound when the invitation was already used, so it can be accepted only once
+func (h *householdRepo) DeleteInvitation(ctx context.Context, token string) error {
+	deleted, err := h.client.Del(ctx, householdInvitationPrefix+token).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// ShareResource links a resource to a household. A resource can be shared with one household only,
+// it returns false when it is already shared with another one.
+func (h *householdRepo) ShareResource(ctx context.Context, householdId string, resource models.SharedResource) (bool, error) {
+	key := householdSharedPrefix + resource.Type + ":" + resource.ResourceId
+
+	ok, err := h.client.SetNX(ctx, key, householdId, 0).Result()
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		current, err := h.client.Get(ctx, key).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return false, err
+		}
+		if current != householdId {
+			return false, nil
+		}
+	}
+
+	err = h.client.SAdd(ctx, householdResourcesPrefix+householdId, resource.Type+":"+resource.ResourceId).Err()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (h *householdRepo) UnshareResource(ctx context.Context, householdId string, resource models.SharedResource) error {
+	key := householdSharedPrefix + resource.Type + ":" + resource.ResourceId
+
+	current, err := h.client.Get(ctx, key).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if current != householdId {
+		return storage.ErrNotFound
+	}
+
+	pipe := h.client.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.SRem(ctx, householdResourcesPrefix+householdId, resource.Type+":"+resource.ResourceId)
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+func (h *householdRepo) GetResourceHousehold(ctx context.Context, resourceType, resourceId string) (string, error) {
+	householdId, err := h.client.Get(ctx, householdSharedPrefix+resourceType+":"+resourceId).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", storage.ErrNotFound
+	}
+
+	return householdId, err
+}
+
+func (h *householdRepo) GetResources(ctx context.Context, householdId string) ([]models.SharedResource, error) {
+	members, err := h.client.SMembers(ctx, householdResourcesPrefix+householdId).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]models.SharedResource, 0, len(members))
+	for _, member := range members {
+		resourceType, resourceId, ok := strings.Cut(member, ":")
+		if !ok {
+			continue
+		}
+		resources = append(resources, models.SharedResource{Type: resourceType, ResourceId: resourceId})
+	}
+
+	return resources, nil
+}
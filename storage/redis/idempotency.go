@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const idempotencyPrefix = "idempotency:"
+
+type idempotencyRepo struct {
+	client *redis.Client
+}
+
+func NewIdempotencyRepo(client *redis.Client) storage.IIdempotencyStorage {
+	return &idempotencyRepo{
+		client: client,
+	}
+}
+
+// Reserve claims key for a request with fingerprint. It returns true when the caller is the first one
+// and has to process the request, otherwise it returns what is stored for key.
+func (i *idempotencyRepo) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotentResponse, bool, error) {
+	pending, err := json.Marshal(&models.IdempotentResponse{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, false, err
+	}
+
+	// the stored response can expire between the two calls, then the key is free to claim again
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := i.client.SetNX(ctx, idempotencyPrefix+key, pending, ttl).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if reserved {
+			return nil, true, nil
+		}
+
+		data, err := i.client.Get(ctx, idempotencyPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		response := models.IdempotentResponse{}
+		if err = json.Unmarshal(data, &response); err != nil {
+			return nil, false, err
+		}
+
+		return &response, false, nil
+	}
+
+	return nil, false, errors.New("idempotency key is changing too fast")
+}
+
+func (i *idempotencyRepo) Complete(ctx context.Context, key string, response *models.IdempotentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return i.client.Set(ctx, idempotencyPrefix+key, data, ttl).Err()
+}
+
+// Release forgets key so that the request can be retried
+func (i *idempotencyRepo) Release(ctx context.Context, key string) error {
+	return i.client.Del(ctx, idempotencyPrefix+key).Err()
+}
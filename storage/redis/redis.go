@@ -2,11 +2,16 @@ package redis
 
 import (
 	"api_gateway/configs"
+	"api_gateway/storage"
 	"context"
 
 	"github.com/redis/go-redis/v9"
 )
 
+type redisStorage struct {
+	client *redis.Client
+}
+
 func ConnectDB(cfg *configs.Config) (*redis.Client, error) {
 
 	client := redis.NewClient(&redis.Options{
@@ -22,3 +27,37 @@ func ConnectDB(cfg *configs.Config) (*redis.Client, error) {
 
 	return client, nil
 }
+
+func NewRedisStorage(client *redis.Client) storage.IStorage {
+	return &redisStorage{
+		client: client,
+	}
+}
+
+func (r *redisStorage) Token() storage.ITokenStorage {
+	return NewTokenRepo(r.client)
+}
+
+func (r *redisStorage) Throttle() storage.IThrottleStorage {
+	return NewThrottleRepo(r.client)
+}
+
+func (r *redisStorage) APIKey() storage.IAPIKeyStorage {
+	return NewAPIKeyRepo(r.client)
+}
+
+func (r *redisStorage) TwoFactor() storage.ITwoFactorStorage {
+	return NewTwoFactorRepo(r.client)
+}
+
+func (r *redisStorage) Household() storage.IHouseholdStorage {
+	return NewHouseholdRepo(r.client)
+}
+
+func (r *redisStorage) Idempotency() storage.IIdempotencyStorage {
+	return NewIdempotencyRepo(r.client)
+}
+
+func (r *redisStorage) ResponseCache() storage.IResponseCacheStorage {
+	return NewResponseCacheRepo(r.client)
+}
@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"api_gateway/api/handlers/models"
+	"api_gateway/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	responseCachePrefix        = "cache:response:"
+	responseCacheVersionPrefix = "cache:version:"
+)
+
+type responseCacheRepo struct {
+	client *redis.Client
+}
+
+func NewResponseCacheRepo(client *redis.Client) storage.IResponseCacheStorage {
+	return &responseCacheRepo{
+		client: client,
+	}
+}
+
+// Version returns the cache generation of a user. Cache keys include it, so bumping it
+// invalidates all responses cached for the user at once.
+func (r *responseCacheRepo) Version(ctx context.Context, userId string) (int64, error) {
+	version, err := r.client.Get(ctx, responseCacheVersionPrefix+userId).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+
+	return version, err
+}
+
+func (r *responseCacheRepo) Get(ctx context.Context, key string) (*models.CachedResponse, error) {
+	data, err := r.client.Get(ctx, responseCachePrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := models.CachedResponse{}
+	if err = json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (r *responseCacheRepo) Set(ctx context.Context, key string, response *models.CachedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, responseCachePrefix+key, data, ttl).Err()
+}
+
+// Invalidate bumps the versions of the users, the stale responses expire on their own
+func (r *responseCacheRepo) Invalidate(ctx context.Context, userIds ...string) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for _, userId := range userIds {
+		pipe.Incr(ctx, responseCacheVersionPrefix+userId)
+	}
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"api_gateway/pkg/ratelimit"
+	"api_gateway/storage"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	throttlePrefix  = "throttle:"
+	rateLimitPrefix = "ratelimit:"
+)
+
+var hitScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// slidingScript implements a sliding window counter. KEYS are the counters of the current and the previous
+// fixed window, the previous one is weighted by the part of it that is still inside the sliding window.
+var slidingScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+local count = math.floor(previous * (window - elapsed) / window) + current
+if count >= limit then
+	return {count, 0}
+end
+redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], window * 2)
+return {count + 1, 1}
+`)
+
+type throttleRepo struct {
+	client *redis.Client
+}
+
+func NewThrottleRepo(client *redis.Client) storage.IThrottleStorage {
+	return &throttleRepo{
+		client: client,
+	}
+}
+
+// Hit counts one attempt for key in a fixed window and returns the number of attempts made in it so far
+func (t *throttleRepo) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return hitScript.Run(ctx, t.client, []string{throttlePrefix + key}, window.Milliseconds()).Int64()
+}
+
+// Allow counts a request for key in a sliding window and reports whether it fits in limit. It returns the number
+// of requests in the window including this one, or the current number if the request was refused.
+func (t *throttleRepo) Allow(ctx context.Context, key string, limit int64, window time.Duration) (int64, bool, error) {
+	slot, elapsed := ratelimit.Slot(time.Now(), window)
+	keys := []string{
+		rateLimitPrefix + key + ":" + strconv.FormatInt(slot, 10),
+		rateLimitPrefix + key + ":" + strconv.FormatInt(slot-1, 10),
+	}
+
+	res, err := slidingScript.Run(ctx, t.client, keys, limit, window.Milliseconds(), elapsed.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+
+	return res[0], res[1] == 1, nil
+}
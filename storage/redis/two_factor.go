@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"api_gateway/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	totpPendingPrefix  = "totp:pending:"
+	totpSecretPrefix   = "totp:secret:"
+	totpRecoveryPrefix = "totp:recovery:"
+	totpUsedStepPrefix = "totp:used_step:"
+)
+
+type twoFactorRepo struct {
+	client *redis.Client
+}
+
+func NewTwoFactorRepo(client *redis.Client) storage.ITwoFactorStorage {
+	return &twoFactorRepo{
+		client: client,
+	}
+}
+
+func (t *twoFactorRepo) SetPendingSecret(ctx context.Context, userId, secret string, ttl time.Duration) error {
+	return t.client.Set(ctx, totpPendingPrefix+userId, secret, ttl).Err()
+}
+
+func (t *twoFactorRepo) GetPendingSecret(ctx context.Context, userId string) (string, error) {
+	return t.get(ctx, totpPendingPrefix+userId)
+}
+
+// Enable activates the secret and replaces any previous recovery codes
+func (t *twoFactorRepo) Enable(ctx context.Context, userId, secret string, recoveryCodeHashes []string) error {
+	members := make([]interface{}, 0, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		members = append(members, hash)
+	}
+
+	pipe := t.client.TxPipeline()
+	pipe.Set(ctx, totpSecretPrefix+userId, secret, 0)
+	pipe.Del(ctx, totpRecoveryPrefix+userId)
+	if len(members) > 0 {
+		pipe.SAdd(ctx, totpRecoveryPrefix+userId, members...)
+	}
+	pipe.Del(ctx, totpPendingPrefix+userId)
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func (t *twoFactorRepo) GetSecret(ctx context.Context, userId string) (string, error) {
+	return t.get(ctx, totpSecretPrefix+userId)
+}
+
+// UseRecoveryCode consumes the recovery code and reports whether it was valid
+func (t *twoFactorRepo) UseRecoveryCode(ctx context.Context, userId, hash string) (bool, error) {
+	n, err := t.client.SRem(ctx, totpRecoveryPrefix+userId, hash).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// MarkStepUsed records that a code of the given time step was accepted and reports whether it was the first time
+func (t *twoFactorRepo) MarkStepUsed(ctx context.Context, userId string, step int64, ttl time.Duration) (bool, error) {
+	return t.client.SetNX(ctx, fmt.Sprintf("%s%s:%d", totpUsedStepPrefix, userId, step), 1, ttl).Result()
+}
+
+func (t *twoFactorRepo) Disable(ctx context.Context, userId string) error {
+	return t.client.Del(ctx, totpSecretPrefix+userId, totpRecoveryPrefix+userId, totpPendingPrefix+userId).Err()
+}
+
+func (t *twoFactorRepo) get(ctx context.Context, key string) (string, error) {
+	value, err := t.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", storage.ErrNotFound
+	}
+
+	return value, err
+}
@@ -1 +1,77 @@
 package storage
+
+import (
+	"api_gateway/api/handlers/models"
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type IStorage interface {
+	Token() ITokenStorage
+	Throttle() IThrottleStorage
+	APIKey() IAPIKeyStorage
+	TwoFactor() ITwoFactorStorage
+	Household() IHouseholdStorage
+	Idempotency() IIdempotencyStorage
+	ResponseCache() IResponseCacheStorage
+}
+
+type ITokenStorage interface {
+	MarkRefreshTokenUsed(ctx context.Context, jti string, ttl time.Duration) (bool, error)
+	RevokeTokenFamily(ctx context.Context, familyId string, ttl time.Duration) error
+	IsTokenFamilyRevoked(ctx context.Context, familyId string) (bool, error)
+	RevokeToken(ctx context.Context, jti string, ttl time.Duration) error
+	RevokeUserTokens(ctx context.Context, userId string, notBefore time.Time, ttl time.Duration) error
+	RevokeEmailTokens(ctx context.Context, email string, notBefore time.Time, ttl time.Duration) error
+	IsTokenRevoked(ctx context.Context, jti, userId, email string, issuedAt time.Time) (bool, error)
+}
+
+type IThrottleStorage interface {
+	Hit(ctx context.Context, key string, window time.Duration) (int64, error)
+	Allow(ctx context.Context, key string, limit int64, window time.Duration) (int64, bool, error)
+}
+
+type IAPIKeyStorage interface {
+	Create(ctx context.Context, hash string, key *models.APIKey, ttl time.Duration) error
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	GetAllByUser(ctx context.Context, userId string) ([]*models.APIKey, error)
+	Delete(ctx context.Context, userId, id string) error
+}
+
+type ITwoFactorStorage interface {
+	SetPendingSecret(ctx context.Context, userId, secret string, ttl time.Duration) error
+	GetPendingSecret(ctx context.Context, userId string) (string, error)
+	Enable(ctx context.Context, userId, secret string, recoveryCodeHashes []string) error
+	GetSecret(ctx context.Context, userId string) (string, error)
+	UseRecoveryCode(ctx context.Context, userId, hash string) (bool, error)
+	MarkStepUsed(ctx context.Context, userId string, step int64, ttl time.Duration) (bool, error)
+	Disable(ctx context.Context, userId string) error
+}
+
+type IHouseholdStorage interface {
+	Create(ctx context.Context, household *models.Household) error
+	Get(ctx context.Context, id string) (*models.Household, error)
+	CreateInvitation(ctx context.Context, invitation *models.HouseholdInvitation, ttl time.Duration) error
+	GetInvitation(ctx context.Context, token string) (*models.HouseholdInvitation, error)
+	DeleteInvitation(ctx context.Context, token string) error
+	ShareResource(ctx context.Context, householdId string, resource models.SharedResource) (bool, error)
+	UnshareResource(ctx context.Context, householdId string, resource models.SharedResource) error
+	GetResourceHousehold(ctx context.Context, resourceType, resourceId string) (string, error)
+	GetResources(ctx context.Context, householdId string) ([]models.SharedResource, error)
+}
+
+type IIdempotencyStorage interface {
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotentResponse, bool, error)
+	Complete(ctx context.Context, key string, response *models.IdempotentResponse, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+type IResponseCacheStorage interface {
+	Version(ctx context.Context, userId string) (int64, error)
+	Get(ctx context.Context, key string) (*models.CachedResponse, error)
+	Set(ctx context.Context, key string, response *models.CachedResponse, ttl time.Duration) error
+	Invalidate(ctx context.Context, userIds ...string) error
+}